@@ -0,0 +1,130 @@
+//go:build ignore
+
+// Command apng-spinner generates a small animated PNG that exercises the
+// frame dispose and blend operations.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+)
+
+// APNG specification: https://wiki.mozilla.org/APNG_Specification
+const (
+	disposeNone       = 0
+	disposeBackground = 1
+	disposePrevious   = 2
+
+	blendSource = 0
+	blendOver   = 1
+)
+
+func appendChunk(b []byte, typ string, data []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(data)))
+	start := len(b)
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[start:]))
+}
+
+// encode encodes img as PNG and returns the IHDR data and the image data.
+func encode(img image.Image) (ihdr []byte, data []byte) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		panic(err)
+	}
+	b := buf.Bytes()[8:]
+	for len(b) > 0 {
+		n := binary.BigEndian.Uint32(b)
+		switch string(b[4:8]) {
+		case "IHDR":
+			ihdr = b[8 : 8+n]
+		case "IDAT":
+			data = append(data, b[8:8+n]...)
+		}
+		b = b[12+n:]
+	}
+	return
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("Usage: go run apng-spinner.go <output.png>")
+		os.Exit(1)
+	}
+
+	colors := []color.NRGBA{
+		{0x00, 0xad, 0xd8, 0xff}, // Gopher blue
+		{0xfd, 0xdd, 0x00, 0xff}, // Yellow
+		{0xce, 0x32, 0x62, 0xff}, // Fuchsia
+	}
+
+	var ihdr, frames []byte
+	seq := uint32(0)
+	nFrames := 0
+
+	add := func(r image.Rectangle, c int, delay uint16, dispose, blend byte) {
+		img := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				// Checkerboard with transparent cells
+				if (x+y)%2 == 0 {
+					img.SetNRGBA(x-r.Min.X, y-r.Min.Y, colors[c])
+				}
+			}
+		}
+		hdr, data := encode(img)
+
+		var fcTL []byte
+		fcTL = binary.BigEndian.AppendUint32(fcTL, seq)
+		fcTL = binary.BigEndian.AppendUint32(fcTL, uint32(r.Dx()))
+		fcTL = binary.BigEndian.AppendUint32(fcTL, uint32(r.Dy()))
+		fcTL = binary.BigEndian.AppendUint32(fcTL, uint32(r.Min.X))
+		fcTL = binary.BigEndian.AppendUint32(fcTL, uint32(r.Min.Y))
+		fcTL = binary.BigEndian.AppendUint16(fcTL, delay)
+		fcTL = binary.BigEndian.AppendUint16(fcTL, 100)
+		fcTL = append(fcTL, dispose, blend)
+		frames = appendChunk(frames, "fcTL", fcTL)
+		seq++
+
+		if nFrames == 0 {
+			// The default image is the first frame
+			ihdr = hdr
+			frames = appendChunk(frames, "IDAT", data)
+		} else {
+			frames = appendChunk(frames, "fdAT", append(binary.BigEndian.AppendUint32(nil, seq), data...))
+			seq++
+		}
+		nFrames++
+	}
+
+	// Background covering the whole canvas
+	add(image.Rect(0, 0, 16, 16), 0, 10, disposeNone, blendSource)
+	// Top-left quarter, cleared before the next frame
+	add(image.Rect(0, 0, 8, 8), 1, 20, disposeBackground, blendOver)
+	// Top-right quarter, restored before the next frame
+	add(image.Rect(8, 0, 16, 8), 2, 30, disposePrevious, blendSource)
+	// Bottom half
+	add(image.Rect(0, 8, 16, 16), 1, 40, disposeNone, blendOver)
+
+	var acTL []byte
+	acTL = binary.BigEndian.AppendUint32(acTL, uint32(nFrames))
+	acTL = binary.BigEndian.AppendUint32(acTL, 0) // Forever
+
+	out := []byte("\x89PNG\r\n\x1a\n")
+	out = appendChunk(out, "IHDR", ihdr)
+	out = appendChunk(out, "acTL", acTL)
+	out = append(out, frames...)
+	out = appendChunk(out, "IEND", nil)
+
+	if err := os.WriteFile(os.Args[1], out, 0o644); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build ignore
+
+// Command gif-spinner generates a small animated GIF that exercises the
+// frame disposal methods.
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("Usage: go run gif-spinner.go <output.gif>")
+		os.Exit(1)
+	}
+
+	palette := color.Palette{
+		color.Transparent,
+		color.RGBA{0x00, 0xad, 0xd8, 0xff}, // Gopher blue
+		color.RGBA{0xfd, 0xdd, 0x00, 0xff}, // Yellow
+		color.RGBA{0xce, 0x32, 0x62, 0xff}, // Fuchsia
+	}
+
+	g := &gif.GIF{
+		Config: image.Config{
+			ColorModel: palette,
+			Width:      16,
+			Height:     16,
+		},
+		LoopCount: 0, // Forever
+	}
+
+	add := func(r image.Rectangle, c uint8, delay int, disposal byte) {
+		img := image.NewPaletted(r, palette)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				// Checkerboard with transparent cells
+				if (x+y)%2 == 0 {
+					img.SetColorIndex(x, y, c)
+				}
+			}
+		}
+		g.Image = append(g.Image, img)
+		g.Delay = append(g.Delay, delay)
+		g.Disposal = append(g.Disposal, disposal)
+	}
+
+	// Background covering the whole canvas
+	add(image.Rect(0, 0, 16, 16), 1, 10, gif.DisposalNone)
+	// Top-left quarter, cleared before the next frame
+	add(image.Rect(0, 0, 8, 8), 2, 20, gif.DisposalBackground)
+	// Top-right quarter, restored before the next frame
+	add(image.Rect(8, 0, 16, 8), 3, 30, gif.DisposalPrevious)
+	// Bottom half
+	add(image.Rect(0, 8, 16, 16), 2, 40, gif.DisposalNone)
+
+	f, err := os.Create(os.Args[1])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err = gif.EncodeAll(f, g); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err = f.Close(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"io"
+	"strconv"
+)
+
+// Align is the horizontal alignment of images in the terminal window.
+type Align int
+
+const (
+	// AlignLeft displays images at the cursor position.
+	AlignLeft Align = iota
+	// AlignCenter centers images in the window.
+	AlignCenter
+	// AlignRight aligns images on the right edge of the window.
+	AlignRight
+)
+
+// align moves the cursor to align horizontally, following [Encoder.Align],
+// an image of the given size in pixels, and returns the placement to use.
+// The cursor is moved to the start of the line, then to the right over full
+// cells. The remaining offset is applied inside the first cell (X=).
+func (enc *Encoder) align(w io.Writer, size image.Point) (*Placement, error) {
+	p := enc.Placement
+	if enc.Align == AlignLeft || enc.Window == nil || (p != nil && (p.At != nil || p.ParentID != 0)) {
+		return p, nil
+	}
+	cell := enc.Window.CellSize()
+	if cell.X <= 0 || cell.Y <= 0 {
+		return p, nil
+	}
+
+	var pl Placement
+	if p != nil {
+		pl = *p
+	}
+	if !pl.Source.Empty() {
+		size = pl.Source.Size()
+	}
+	// Width of the image on screen
+	width := size.X
+	switch {
+	case pl.Columns > 0:
+		width = pl.Columns * cell.X
+	case pl.Rows > 0 && size.Y > 0:
+		width = size.X * pl.Rows * cell.Y / size.Y
+	}
+
+	offset := enc.Window.Columns*cell.X - width - pl.XOffset
+	if offset <= 0 {
+		return p, nil
+	}
+	if enc.Align == AlignCenter {
+		offset /= 2
+	}
+	offset += pl.XOffset
+
+	move := []byte{'\r'}
+	if cells := offset / cell.X; cells > 0 {
+		move = append(move, "\033["...)
+		move = strconv.AppendInt(move, int64(cells), 10)
+		move = append(move, 'C')
+	}
+	if _, err := w.Write(move); err != nil {
+		return nil, err
+	}
+	pl.XOffset = offset % cell.X
+	return &pl, nil
+}
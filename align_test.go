@@ -0,0 +1,99 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestEncodeAlign(t *testing.T) {
+	// 80 columns of 10 pixels
+	window := &kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480}
+	for _, tc := range []struct {
+		name      string
+		align     kittyimg.Align
+		window    *kittyimg.WindowSize
+		placement *kittyimg.Placement
+		move      string
+		x         string
+	}{
+		{"left", kittyimg.AlignLeft, window, nil, "", ""},
+		{"no-window", kittyimg.AlignCenter, nil, nil, "", ""},
+		{"no-pixels", kittyimg.AlignCenter, &kittyimg.WindowSize{Columns: 80, Rows: 24}, nil, "", ""},
+		// (800-105)/2 = 347
+		{"center", kittyimg.AlignCenter, window, nil, "\r\033[34C", "7"},
+		// 800-105 = 695
+		{"right", kittyimg.AlignRight, window, nil, "\r\033[69C", "5"},
+		// (800-105-3)/2+3 = 349
+		{"center-offset", kittyimg.AlignCenter, window, &kittyimg.Placement{XOffset: 3}, "\r\033[34C", "9"},
+		// (800-100)/2 = 350
+		{"center-columns", kittyimg.AlignCenter, window, &kittyimg.Placement{Columns: 10}, "\r\033[35C", ""},
+		// (800-795)/2 = 2
+		{"center-small", kittyimg.AlignCenter, window, &kittyimg.Placement{Source: image.Rect(0, 0, 795, 10)}, "\r", "2"},
+		{"too-wide", kittyimg.AlignRight, window, &kittyimg.Placement{Columns: 81}, "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			enc := kittyimg.Encoder{Align: tc.align, Window: tc.window, Placement: tc.placement}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, newTestImage(105, 10)); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.Bytes()
+			i := bytes.Index(out, []byte("\033_G"))
+			if got := string(out[:i]); got != tc.move {
+				t.Errorf("got move %q, expected %q", got, tc.move)
+			}
+			params, _ := decodeImage(t, out[i:])
+			if params['X'] != tc.x {
+				t.Errorf("got X=%s, expected X=%s", params['X'], tc.x)
+			}
+		})
+	}
+}
+
+func TestTranscodeAlign(t *testing.T) {
+	f, err := os.Open("testdata/go-favicon-1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	enc := kittyimg.Encoder{
+		Align:  kittyimg.AlignRight,
+		Window: &kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480},
+	}
+	var buf bytes.Buffer
+	if err = enc.Transcode(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	// 800-16 = 784
+	out := buf.Bytes()
+	if !bytes.HasPrefix(out, []byte("\r\033[78C\033_G")) {
+		t.Fatalf("got %q", out[:min(20, len(out))])
+	}
+	params, _ := decodeImage(t, out[len("\r\033[78C"):])
+	if params['X'] != "4" || params['f'] != "100" {
+		t.Errorf("got %v", params)
+	}
+}
@@ -0,0 +1,80 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// newAlphaImage returns an image with semi-transparent pixels.
+func newAlphaImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 0xff, G: uint8(x * 16), B: 0x40, A: uint8(y*16 + 15)})
+		}
+	}
+	return img
+}
+
+// imageOnly hides the concrete type of an image.Image.
+type imageOnly struct{ image.Image }
+
+func testEncodeAlpha(t *testing.T, img image.Image, ref *image.NRGBA, tolerance int) {
+	t.Helper()
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	params, pix := decodeImage(t, buf.Bytes())
+	if params['f'] != "32" {
+		t.Fatalf("f=%s", params['f'])
+	}
+	if len(pix) != len(ref.Pix) {
+		t.Fatalf("got %d bytes, expected %d", len(pix), len(ref.Pix))
+	}
+	for i := range pix {
+		if d := int(pix[i]) - int(ref.Pix[i]); d > tolerance || d < -tolerance {
+			t.Fatalf("pixel %d, channel %d: got %#02x, expected %#02x", i/4, i%4, pix[i], ref.Pix[i])
+		}
+	}
+}
+
+func TestEncodeStraightAlpha(t *testing.T) {
+	ref := newAlphaImage()
+
+	t.Run("NRGBA", func(t *testing.T) {
+		testEncodeAlpha(t, imageOnly{ref}, ref, 0)
+	})
+
+	t.Run("RGBA", func(t *testing.T) {
+		// Alpha-premultiplied copy of ref
+		rgba := image.NewRGBA(ref.Bounds())
+		draw.Draw(rgba, rgba.Bounds(), ref, image.Point{}, draw.Src)
+		// Premultiplication loses precision for low alpha values
+		testEncodeAlpha(t, imageOnly{rgba}, ref, 255/15)
+	})
+}
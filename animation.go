@@ -0,0 +1,275 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// Animation states (s=) for the animation control command (a=a).
+// https://sw.kovidgoyal.net/kitty/graphics-protocol/#controlling-animations
+const (
+	animationStop = 1
+	animationRun  = 3
+)
+
+// randomID returns a random non-zero image ID, for animations transmitted
+// without an explicit image ID.
+func randomID() uint32 {
+	for {
+		if id := rand.Uint32(); id != 0 {
+			return id
+		}
+	}
+}
+
+// Frame is a frame of an [Animation].
+type Frame struct {
+	Image    image.Image
+	Duration time.Duration // Rounded to milliseconds. 0 for the terminal default.
+}
+
+// Animation is a sequence of frames displayed as a kitty [animation].
+//
+// All frames must have the same size (once resized to [Encoder.Fit]).
+// Use [Encoder.EncodeAnimation] to display it.
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
+type Animation struct {
+	Frames []Frame
+
+	// Loops is the number of times the animation is played. 0 means forever.
+	Loops int
+
+	// Diff enables sending, for each frame after the first one, only the
+	// rectangle which changed from the previous frame, instead of the full frame.
+	Diff bool
+}
+
+// errNoFrames is returned by [Encoder.EncodeAnimation] for an empty animation.
+var errNoFrames = errors.New("kittyimg: animation has no frames")
+
+// errNoImage is returned by [Encoder.EncodeAnimation] for a frame without image.
+var errNoImage = errors.New("kittyimg: animation frame has no image")
+
+// errFrameSize is returned by [Encoder.EncodeAnimation] if the frames of the
+// animation don't have the same size.
+var errFrameSize = errors.New("kittyimg: animation frames must have the same size")
+
+// EncodeAnimation displays anim at the cursor position (like [Encoder.Encode])
+// and starts playing it. The animation is stored by the terminal with the
+// image ID id, or with a random ID if id is 0.
+//
+// The returned handle allows to control the animation with [Image.Play],
+// [Image.Stop] and [Image.SetFrame].
+func (enc *Encoder) EncodeAnimation(w io.Writer, id uint32, anim *Animation) (*Image, error) {
+	if len(anim.Frames) == 0 {
+		return nil, errNoFrames
+	}
+	if err := enc.checkPlacement(); err != nil {
+		return nil, err
+	}
+
+	// Check the frames before writing anything
+	images := make([]image.Image, len(anim.Frames))
+	for i, frame := range anim.Frames {
+		if frame.Image == nil {
+			return nil, errNoImage
+		}
+		images[i] = enc.resize(frame.Image)
+		if images[i].Bounds().Size() != images[0].Bounds().Size() {
+			return nil, errFrameSize
+		}
+	}
+
+	if id == 0 {
+		id = randomID()
+	}
+
+	root := images[0]
+	err := displayAt(w, enc.Placement, func() error {
+		return enc.encode(w, root, 'T', id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Like for other frames, the terminal default applies to a zero duration
+	if gap := durationMs(anim.Frames[0].Duration); gap != 0 {
+		if err := setFrameGap(w, id, 1, gap); err != nil {
+			return nil, err
+		}
+	}
+
+	prev := root
+	for i, frame := range anim.Frames[1:] {
+		img := images[i+1]
+		resized, bounds := img, img.Bounds()
+		base, at := 0, image.Point{}
+		if anim.Diff {
+			r := diffRect(prev, img)
+			if r.Empty() {
+				r = image.Rectangle{Min: bounds.Min, Max: bounds.Min.Add(image.Pt(1, 1))}
+			}
+			if sub, ok := img.(interface {
+				SubImage(image.Rectangle) image.Image
+			}); ok {
+				img = sub.SubImage(r)
+				base, at = i+1, r.Min.Sub(bounds.Min)
+			}
+		}
+		if err := enc.encodeFrame(w, id, img, at, base, base != 0, durationMs(frame.Duration)); err != nil {
+			return nil, err
+		}
+		prev = resized
+	}
+
+	if err := runAnimation(w, id, anim.Loops); err != nil {
+		return nil, err
+	}
+
+	bounds := root.Bounds()
+	return &Image{ID: id, Width: bounds.Dx(), Height: bounds.Dy()}, nil
+}
+
+// durationMs converts d to a frame gap in milliseconds.
+func durationMs(d time.Duration) int {
+	return int(d.Round(time.Millisecond).Milliseconds())
+}
+
+// diffRect returns the smallest rectangle containing the pixels that differ
+// between a and b, which must have the same size. The result is in the
+// coordinates space of b.
+func diffRect(a, b image.Image) image.Rectangle {
+	boundsA, boundsB := a.Bounds(), b.Bounds()
+	if boundsA.Size() != boundsB.Size() {
+		return boundsB
+	}
+	appendA, appendB := rgbaAppender(a), rgbaAppender(b)
+	w := boundsB.Dx()
+	rowA, rowB := make([]byte, 0, 4*w), make([]byte, 0, 4*w)
+	var r image.Rectangle
+	for y := 0; y < boundsB.Dy(); y++ {
+		rowA = appendA(rowA[:0], boundsA.Min.X, boundsA.Min.Y+y, w)
+		rowB = appendB(rowB[:0], boundsB.Min.X, boundsB.Min.Y+y, w)
+		if bytes.Equal(rowA, rowB) {
+			continue
+		}
+		x0 := 0
+		for bytes.Equal(rowA[4*x0:4*x0+4], rowB[4*x0:4*x0+4]) {
+			x0++
+		}
+		x1 := w
+		for bytes.Equal(rowA[4*x1-4:4*x1], rowB[4*x1-4:4*x1]) {
+			x1--
+		}
+		r = r.Union(image.Rect(x0, y, x1, y+1))
+	}
+	return r.Add(boundsB.Min)
+}
+
+// encodeFrame transmits img as a new [animation frame] (a=f) of the image id.
+//
+// at is the location of img in the frame (x=, y=). The frame is built over the
+// frame number base (1-based), or over a blank canvas if base is 0. If
+// replace is true, the pixels of img replace the pixels of the base frame (X=1)
+// instead of being alpha blended. gap is the duration of the frame in
+// milliseconds (z=), 0 for the terminal default.
+//
+// [animation frame]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#transferring-animation-frame-data
+func (enc *Encoder) encodeFrame(w io.Writer, id uint32, img image.Image, at image.Point, base int, replace bool, gap int) error {
+	format := enc.format(img)
+	cmd := appendCommand(enc.cmd[:0], 'f', id)
+	cmd = appendKeyInt(cmd, 'f', int(format))
+	cmd = appendFrame(cmd, img.Bounds().Size(), at, base, replace, gap)
+	return enc.send(w, cmd, img, format)
+}
+
+// appendFrame appends the keys of a frame command for data of the given size.
+// See [Encoder.encodeFrame] for the other arguments.
+func appendFrame(cmd []byte, size image.Point, at image.Point, base int, replace bool, gap int) []byte {
+	cmd = appendKeyInt(cmd, 's', size.X)
+	cmd = appendKeyInt(cmd, 'v', size.Y)
+	if at.X != 0 {
+		cmd = appendKeyInt(cmd, 'x', at.X)
+	}
+	if at.Y != 0 {
+		cmd = appendKeyInt(cmd, 'y', at.Y)
+	}
+	if base != 0 {
+		cmd = appendKeyInt(cmd, 'c', base)
+	}
+	if replace {
+		cmd = appendKeyInt(cmd, 'X', 1)
+	}
+	if gap != 0 {
+		cmd = appendKeyInt(cmd, 'z', gap)
+	}
+	return cmd
+}
+
+// writeAnimationControl writes an animation control command (a=a).
+func writeAnimationControl(w io.Writer, id uint32, keys func([]byte) []byte) error {
+	cmd := appendCommand(make([]byte, 0, 64), 'a', id)
+	cmd = keys(cmd)
+	_, err := w.Write(append(cmd, ";"+apcEnd...))
+	return err
+}
+
+// setFrameGap sets the duration of the frame number frame (1-based) to gap
+// milliseconds (a=a,r=frame,z=gap).
+func setFrameGap(w io.Writer, id uint32, frame int, gap int) error {
+	return writeAnimationControl(w, id, func(cmd []byte) []byte {
+		cmd = appendKeyInt(cmd, 'r', frame)
+		return appendKeyInt(cmd, 'z', gap)
+	})
+}
+
+// runAnimation starts the animation (a=a,s=3) to be played loops times,
+// or forever if loops is 0.
+func runAnimation(w io.Writer, id uint32, loops int) error {
+	return writeAnimationControl(w, id, func(cmd []byte) []byte {
+		cmd = appendKeyInt(cmd, 's', animationRun)
+		// v=1 means forever, v=n means n-1 loops
+		return appendKeyInt(cmd, 'v', loops+1)
+	})
+}
+
+// Play starts playing the animation of the image (a=a,s=3) loops times, or
+// forever if loops is 0.
+func (img *Image) Play(w io.Writer, loops int) error {
+	return runAnimation(w, img.ID, loops)
+}
+
+// Stop stops the animation of the image (a=a,s=1).
+func (img *Image) Stop(w io.Writer) error {
+	return writeAnimationControl(w, img.ID, func(cmd []byte) []byte {
+		return appendKeyInt(cmd, 's', animationStop)
+	})
+}
+
+// SetFrame makes frame (1-based) the current frame of the animation of the
+// image (a=a,c=frame).
+func (img *Image) SetFrame(w io.Writer, frame int) error {
+	return writeAnimationControl(w, img.ID, func(cmd []byte) []byte {
+		return appendKeyInt(cmd, 'c', frame)
+	})
+}
@@ -0,0 +1,35 @@
+//go:build go1.23
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"iter"
+	"time"
+)
+
+// CollectAnimation returns an [Animation] built from the sequence of frames
+// and their durations.
+func CollectAnimation(frames iter.Seq2[image.Image, time.Duration]) *Animation {
+	var anim Animation
+	for img, d := range frames {
+		anim.Frames = append(anim.Frames, Frame{Image: img, Duration: d})
+	}
+	return &anim
+}
@@ -0,0 +1,182 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// spinner yields frames of a bar moving over a transparent background.
+func spinner(yield func(image.Image, time.Duration) bool) {
+	for i := range 4 {
+		img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+		draw.Draw(img, image.Rect(i*2, 2, i*2+2, 6), image.NewUniform(color.NRGBA{0xff, 0, 0, 0xff}), image.Point{}, draw.Src)
+		if !yield(img, time.Duration(i+1)*50*time.Millisecond) {
+			return
+		}
+	}
+}
+
+func TestEncodeAnimation(t *testing.T) {
+	for _, tc := range []struct {
+		diff     bool
+		expected []string
+	}{
+		{false, []string{
+			"a=T,f=32,i=9,o=z,q=1,s=8,t=d,v=8",
+			"a=a,i=9,q=1,r=1,z=50",
+			"a=f,f=32,i=9,o=z,q=1,s=8,t=d,v=8,z=100",
+			"a=f,f=32,i=9,o=z,q=1,s=8,t=d,v=8,z=150",
+			"a=f,f=32,i=9,o=z,q=1,s=8,t=d,v=8,z=200",
+			"a=a,i=9,q=1,s=3,v=3",
+		}},
+		{true, []string{
+			"a=T,f=32,i=9,o=z,q=1,s=8,t=d,v=8",
+			"a=a,i=9,q=1,r=1,z=50",
+			"X=1,a=f,c=1,f=32,i=9,o=z,q=1,s=4,t=d,v=4,y=2,z=100",
+			"X=1,a=f,c=2,f=32,i=9,o=z,q=1,s=4,t=d,v=4,x=2,y=2,z=150",
+			"X=1,a=f,c=3,f=32,i=9,o=z,q=1,s=4,t=d,v=4,x=4,y=2,z=200",
+			"a=a,i=9,q=1,s=3,v=3",
+		}},
+	} {
+		name := "full"
+		if tc.diff {
+			name = "diff"
+		}
+		t.Run(name, func(t *testing.T) {
+			anim := kittyimg.CollectAnimation(spinner)
+			anim.Loops = 2
+			anim.Diff = tc.diff
+
+			var enc kittyimg.Encoder
+			var buf bytes.Buffer
+			img, err := enc.EncodeAnimation(&buf, 9, anim)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.ID != 9 || img.Width != 8 || img.Height != 8 {
+				t.Errorf("unexpected handle: %+v", img)
+			}
+
+			i := 0
+			for bl := range extractBlocks(buf.Bytes()) {
+				if i >= len(tc.expected) {
+					t.Fatalf("unexpected block %d: %s", i, bl.Params)
+				}
+				if got := bl.Params.String(); got != tc.expected[i] {
+					t.Errorf("block %d: got %q, expected %q", i, got, tc.expected[i])
+				}
+				i++
+			}
+			if i != len(tc.expected) {
+				t.Errorf("got %d blocks, expected %d", i, len(tc.expected))
+			}
+		})
+	}
+}
+
+func TestEncodeAnimationEmpty(t *testing.T) {
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if _, err := enc.EncodeAnimation(&buf, 1, &kittyimg.Animation{}); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestEncodeAnimationDefaultGap(t *testing.T) {
+	anim := &kittyimg.Animation{Frames: []kittyimg.Frame{
+		{Image: newTestImage(4, 4)},
+		{Image: newTestImage(4, 4)},
+	}}
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if _, err := enc.EncodeAnimation(&buf, 9, anim); err != nil {
+		t.Fatal(err)
+	}
+	for bl := range extractBlocks(buf.Bytes()) {
+		if _, ok := bl.Params['z']; ok {
+			t.Errorf("unexpected gap: %s", bl.Params)
+		}
+	}
+}
+
+func TestEncodeAnimationNilImage(t *testing.T) {
+	anim := &kittyimg.Animation{Frames: []kittyimg.Frame{
+		{Image: newTestImage(4, 4)},
+		{},
+	}}
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if _, err := enc.EncodeAnimation(&buf, 9, anim); err == nil {
+		t.Error("error expected")
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
+
+func TestEncodeAnimationFrameSize(t *testing.T) {
+	anim := &kittyimg.Animation{Frames: []kittyimg.Frame{
+		{Image: newTestImage(4, 4)},
+		{Image: newTestImage(4, 4)},
+		{Image: newTestImage(8, 4)},
+	}}
+	for _, diff := range []bool{false, true} {
+		anim.Diff = diff
+		var enc kittyimg.Encoder
+		var buf bytes.Buffer
+		if _, err := enc.EncodeAnimation(&buf, 1, anim); err == nil {
+			t.Errorf("Diff=%t: error expected", diff)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Diff=%t: unexpected output %q", diff, buf.String()[:min(buf.Len(), 20)])
+		}
+	}
+
+	// Frames resized to the same size are accepted
+	enc := kittyimg.Encoder{Fit: image.Pt(4, 4)}
+	anim.Frames[2].Image = newTestImage(8, 8)
+	if _, err := enc.EncodeAnimation(io.Discard, 1, anim); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImageAnimationControl(t *testing.T) {
+	img := kittyimg.Image{ID: 4}
+	var sb strings.Builder
+	img.Play(&sb, 0)
+	img.Stop(&sb)
+	img.SetFrame(&sb, 2)
+	expected := "" +
+		"\033_Gq=1,a=a,i=4,s=3,v=1;\033\\" +
+		"\033_Gq=1,a=a,i=4,s=1;\033\\" +
+		"\033_Gq=1,a=a,i=4,c=2;\033\\"
+	if got := sb.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,338 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// APNG specification: https://wiki.mozilla.org/APNG_Specification
+
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+// APNG frame dispose_op values
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+)
+
+// APNG frame blend_op values
+const (
+	apngBlendSource = 0
+	apngBlendOver   = 1
+)
+
+var errBadAPNG = errors.New("kittyimg: invalid APNG")
+
+// isAPNG reads the chunks of the PNG stream r up to the image data and
+// reports whether an animation control chunk (acTL) was found.
+func isAPNG(r io.Reader) (bool, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:len(pngSignature)]); err != nil {
+		return false, err
+	}
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return false, err
+		}
+		switch string(hdr[4:]) {
+		case "acTL":
+			return true, nil
+		case "IDAT", "IEND":
+			return false, nil
+		}
+		// Skip chunk data and CRC
+		if _, err := io.CopyN(io.Discard, r, int64(binary.BigEndian.Uint32(hdr[:4]))+4); err != nil {
+			return false, err
+		}
+	}
+}
+
+type apngFrame struct {
+	bounds  image.Rectangle
+	delay   int // milliseconds
+	dispose byte
+	blend   byte
+	data    [][]byte // content of IDAT or fdAT chunks (without sequence number)
+}
+
+// apng is an APNG file split into frames.
+type apng struct {
+	ihdr   []byte   // IHDR chunk data
+	header [][]byte // raw chunks (PLTE, tRNS...) that come before the image data
+	plays  int      // 0 is forever
+	frames []apngFrame
+}
+
+// parseAPNG splits the APNG file data into frames.
+// Chunk CRCs are not checked: the terminal will check those that are sent.
+func parseAPNG(data []byte) (*apng, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, errBadAPNG
+	}
+	data = data[len(pngSignature):]
+
+	var a apng
+	var frame *apngFrame
+	seenIDAT := false
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		if uint64(length) > uint64(len(data)-12) {
+			return nil, errBadAPNG
+		}
+		chunk := data[:12+length]
+		data = data[12+length:]
+		typ, body := string(chunk[4:8]), chunk[8:8+length]
+
+		switch typ {
+		case "IHDR":
+			if length != 13 {
+				return nil, errBadAPNG
+			}
+			a.ihdr = body
+		case "acTL":
+			if length != 8 {
+				return nil, errBadAPNG
+			}
+			a.plays = int(binary.BigEndian.Uint32(body[4:]))
+		case "fcTL":
+			if length != 26 || len(a.ihdr) == 0 {
+				return nil, errBadAPNG
+			}
+			x, y := int(binary.BigEndian.Uint32(body[12:])), int(binary.BigEndian.Uint32(body[16:]))
+			a.frames = append(a.frames, apngFrame{
+				bounds: image.Rect(x, y,
+					x+int(binary.BigEndian.Uint32(body[4:])),
+					y+int(binary.BigEndian.Uint32(body[8:]))),
+				delay:   apngDelay(binary.BigEndian.Uint16(body[20:]), binary.BigEndian.Uint16(body[22:])),
+				dispose: body[24],
+				blend:   body[25],
+			})
+			frame = &a.frames[len(a.frames)-1]
+			if frame.bounds.Empty() || !frame.bounds.In(image.Rectangle{Max: a.size()}) {
+				return nil, errBadAPNG
+			}
+		case "IDAT":
+			seenIDAT = true
+			// The default image is the first frame only if a fcTL precedes it
+			if frame != nil {
+				frame.data = append(frame.data, body)
+			}
+		case "fdAT":
+			if frame == nil || length < 4 {
+				return nil, errBadAPNG
+			}
+			frame.data = append(frame.data, body[4:])
+		case "IEND":
+			data = nil
+		default:
+			if !seenIDAT {
+				a.header = append(a.header, chunk)
+			}
+		}
+	}
+	if len(a.frames) == 0 {
+		return nil, errBadAPNG
+	}
+	for i := range a.frames {
+		if len(a.frames[i].data) == 0 {
+			return nil, errBadAPNG
+		}
+	}
+	// The first frame covers the whole canvas
+	a.frames[0].blend = apngBlendSource
+	return &a, nil
+}
+
+// apngDelay converts an APNG frame delay (in num/den seconds) to a frame gap
+// in milliseconds.
+func apngDelay(num, den uint16) int {
+	if den == 0 {
+		den = 100
+	}
+	ms := int(num) * 1000 / int(den)
+	if ms <= 10 {
+		// Like browsers do
+		return 100
+	}
+	return ms
+}
+
+// size returns the size of the canvas.
+func (a *apng) size() image.Point {
+	return image.Pt(int(binary.BigEndian.Uint32(a.ihdr)), int(binary.BigEndian.Uint32(a.ihdr[4:])))
+}
+
+// appendFramePNG appends to b a standalone PNG file with the image of frame.
+func (a *apng) appendFramePNG(b []byte, frame *apngFrame) []byte {
+	b = append(b, pngSignature...)
+	var ihdr [13]byte
+	copy(ihdr[:], a.ihdr)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(frame.bounds.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(frame.bounds.Dy()))
+	b = appendPNGChunk(b, "IHDR", ihdr[:])
+	for _, chunk := range a.header {
+		b = append(b, chunk...)
+	}
+	for _, data := range frame.data {
+		b = appendPNGChunk(b, "IDAT", data)
+	}
+	return appendPNGChunk(b, "IEND", nil)
+}
+
+func appendPNGChunk(b []byte, typ string, data []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(data)))
+	start := len(b)
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[start:]))
+}
+
+// encodeAPNG transmits the frames of the APNG file a as an [animation].
+// Each frame is sent as PNG data (f=100), composed over the frame that
+// reflects the canvas after disposal of the previous frame.
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
+func (enc *Encoder) encodeAPNG(w io.Writer, a *apng, action byte, id uint32) error {
+	if size := a.size(); enc.fitSize(size) != size {
+		return enc.encodeAPNGResized(w, a, action, id)
+	}
+
+	// Frames must refer to the image
+	if id == 0 {
+		id = randomID()
+	}
+
+	var (
+		buf []byte
+		err error
+		// The canvas before the current frame is the frame number base
+		// with the area cleared made transparent
+		base    int
+		cleared image.Rectangle
+	)
+	for i := range a.frames {
+		frame := &a.frames[i]
+		n := i + 1
+		buf = a.appendFramePNG(buf[:0], frame)
+
+		var cmd []byte
+		if i == 0 {
+			var p *Placement
+			if action == 'T' {
+				if p, err = enc.align(w, frame.bounds.Size()); err != nil {
+					return err
+				}
+			}
+			cmd = appendCommand(enc.cmd[:0], action, id)
+			cmd = appendKeyInt(cmd, 'f', 100)
+			cmd = appendKeyInt(cmd, 's', frame.bounds.Dx())
+			cmd = appendKeyInt(cmd, 'v', frame.bounds.Dy())
+			if action == 'T' {
+				cmd = appendPlacement(cmd, p)
+			}
+		} else if cleared.Empty() || (frame.blend == apngBlendSource && cleared.In(frame.bounds)) {
+			cmd = appendCommand(enc.cmd[:0], 'f', id)
+			cmd = appendKeyInt(cmd, 'f', 100)
+			cmd = appendFrame(cmd, frame.bounds.Size(), frame.bounds.Min, base, frame.blend == apngBlendSource, frame.delay)
+		} else {
+			// Create the frame from the base frame with the area cleared,
+			// then draw the image over it by editing the frame (r=)
+			blank := image.NewNRGBA(cleared)
+			cmd = appendCommand(enc.cmd[:0], 'f', id)
+			cmd = appendKeyInt(cmd, 'f', int(FormatRGBA))
+			cmd = appendFrame(cmd, cleared.Size(), cleared.Min, base, true, frame.delay)
+			if err = enc.send(w, cmd, blank, FormatRGBA); err != nil {
+				return err
+			}
+			cmd = appendCommand(enc.cmd[:0], 'f', id)
+			cmd = appendKeyInt(cmd, 'r', n)
+			cmd = appendKeyInt(cmd, 'f', 100)
+			cmd = appendFrame(cmd, frame.bounds.Size(), frame.bounds.Min, 0, frame.blend == apngBlendSource, 0)
+		}
+		if err = enc.sendRaw(w, cmd, bytes.NewReader(buf)); err != nil {
+			return err
+		}
+
+		if len(a.frames) == 1 {
+			return nil
+		}
+		if i == 0 {
+			if err = setFrameGap(w, id, 1, frame.delay); err != nil {
+				return err
+			}
+		}
+
+		// Dispose the frame before the next one
+		switch frame.dispose {
+		case apngDisposeBackground:
+			base, cleared = n, frame.bounds
+		case apngDisposePrevious:
+			// Back to the canvas before the frame (blank for the first frame)
+		default:
+			base, cleared = n, image.Rectangle{}
+		}
+	}
+
+	return runAnimation(w, id, a.plays)
+}
+
+// apngDisposal maps APNG dispose_op values to GIF disposal methods.
+var apngDisposal = [...]byte{
+	apngDisposeNone:       gif.DisposalNone,
+	apngDisposeBackground: gif.DisposalBackground,
+	apngDisposePrevious:   gif.DisposalPrevious,
+}
+
+// encodeAPNGResized transmits the APNG file a as an [animation] resized to
+// [Encoder.Fit]. Frames are decoded and composed like GIF frames.
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
+func (enc *Encoder) encodeAPNGResized(w io.Writer, a *apng, action byte, id uint32) error {
+	var buf []byte
+	return enc.encodeCanvas(w, a.size(), len(a.frames), func(i int) (canvasFrame, error) {
+		frame := &a.frames[i]
+		buf = a.appendFramePNG(buf[:0], frame)
+		// The PNG decoder is registered as the file was detected as PNG
+		img, _, err := image.Decode(bytes.NewReader(buf))
+		if err != nil {
+			return canvasFrame{}, err
+		}
+		f := canvasFrame{
+			img:      img,
+			bounds:   frame.bounds,
+			op:       draw.Over,
+			disposal: gif.DisposalNone,
+			delay:    frame.delay,
+		}
+		if frame.blend == apngBlendSource {
+			f.op = draw.Src
+		}
+		if int(frame.dispose) < len(apngDisposal) {
+			f.disposal = apngDisposal[frame.dispose]
+		}
+		return f, nil
+	}, a.plays, action, id)
+}
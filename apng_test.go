@@ -0,0 +1,138 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestTranscodeAPNG(t *testing.T) {
+	f, err := os.Open("testdata/spinner.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if err = enc.Transcode(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []*Block
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		blocks = append(blocks, bl)
+	}
+	if len(blocks) != 8 {
+		t.Fatalf("got %d blocks, expected 8", len(blocks))
+	}
+
+	id := blocks[0].Params['i']
+	if id == "" {
+		t.Fatal("missing image ID")
+	}
+	for i, expected := range []string{
+		"a=T,f=100,i=" + id + ",q=1,s=16,v=16",
+		"a=a,i=" + id + ",q=1,r=1,z=100",
+		// Blend over frame 1
+		"a=f,c=1,f=100,i=" + id + ",q=1,s=8,v=8,z=200",
+		// Frame 2 disposed to background: new frame with the area cleared...
+		"X=1,a=f,c=2,f=32,i=" + id + ",o=z,q=1,s=8,t=d,v=8,z=300",
+		// ...then edited
+		"X=1,a=f,f=100,i=" + id + ",q=1,r=3,s=8,v=8,x=8",
+		// Frame 3 disposed to previous, which is frame 2 disposed to background
+		"X=1,a=f,c=2,f=32,i=" + id + ",o=z,q=1,s=8,t=d,v=8,z=400",
+		"a=f,f=100,i=" + id + ",q=1,r=4,s=16,v=8,y=8",
+		"a=a,i=" + id + ",q=1,s=3,v=1",
+	} {
+		if got := blocks[i].Params.String(); got != expected {
+			t.Errorf("block %d: got %q, expected %q", i, got, expected)
+		}
+	}
+
+	var (
+		transparent = color.NRGBA{}
+		blue        = color.NRGBA{0x00, 0xad, 0xd8, 0xff}
+		yellow      = color.NRGBA{0xfd, 0xdd, 0x00, 0xff}
+		fuchsia     = color.NRGBA{0xce, 0x32, 0x62, 0xff}
+	)
+	for _, tc := range []struct {
+		block  int
+		bounds image.Rectangle
+		color  color.NRGBA
+	}{
+		{0, image.Rect(0, 0, 16, 16), blue},
+		{2, image.Rect(0, 0, 8, 8), yellow},
+		{4, image.Rect(8, 0, 16, 8), fuchsia},
+		{6, image.Rect(0, 8, 16, 16), yellow},
+	} {
+		img, err := png.Decode(bytes.NewReader(blocks[tc.block].Payload))
+		if err != nil {
+			t.Fatalf("block %d: %v", tc.block, err)
+		}
+		if img.Bounds().Size() != tc.bounds.Size() {
+			t.Fatalf("block %d: got size %v, expected %v", tc.block, img.Bounds().Size(), tc.bounds.Size())
+		}
+		for y := tc.bounds.Min.Y; y < tc.bounds.Max.Y; y++ {
+			for x := tc.bounds.Min.X; x < tc.bounds.Max.X; x++ {
+				exp := transparent
+				if (x+y)%2 == 0 {
+					exp = tc.color
+				}
+				got := color.NRGBAModel.Convert(img.At(x-tc.bounds.Min.X, y-tc.bounds.Min.Y))
+				if got != exp {
+					t.Fatalf("block %d: pixel (%d, %d): got %v, expected %v", tc.block, x, y, got, exp)
+				}
+			}
+		}
+	}
+	for _, i := range []int{3, 5} {
+		checkFrame(t, blocks[i], func(x, y int) color.NRGBA { return transparent })
+	}
+}
+
+func TestTranscodePNGNotAnimated(t *testing.T) {
+	data, err := os.ReadFile("testdata/go-favicon-1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if err = enc.Transcode(&buf, bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for bl := range extractBlocks(buf.Bytes()) {
+		if !bytes.Equal(bl.Payload, data) {
+			t.Error("PNG not sent verbatim")
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d blocks", n)
+	}
+}
@@ -19,7 +19,9 @@
 // Usage
 //
 //	icat < file.png
-//	icat file.png [file.png [...]]
+//	icat [--transfer-mode=auto|stream|file|temp|memory] [--fit] [--scale-up] [--align=left|center|right] file.png [file.png [...]]
+//	icat [--scale-up] --place=WxH@LxT file.png
+//	icat --text [--fit] [--scale-up] file.png [file.png [...]]
 //
 // Install
 //
@@ -31,12 +33,46 @@
 //
 // is equivalent to:
 //
-//	kitten icat --transfer-mode=stream --align=left kitty.png
+//	kitten icat --align=left kitty.png
+//
+// The transfer mode selects how image data is sent to the terminal:
+//
+//   - auto: use temporary files if the terminal can read them (the terminal
+//     runs on the same machine), stream otherwise. This is the default.
+//   - stream: send data in escape codes.
+//   - file: let the terminal read PNG files directly, and other data from
+//     temporary files.
+//   - temp: send data in temporary files.
+//   - memory: send data in POSIX shared memory objects (Linux only).
+//
+// With --fit, images larger than the terminal window are scaled down to fit
+// its width and its height minus one line. With --scale-up, smaller images
+// are also enlarged to fit.
+//
+// With --align=center or --align=right, images are centered or aligned on the
+// right edge of the terminal window.
+//
+// With --place, the image is displayed in the rectangle of W×H cells at
+// column L and row T (from 0 at the top-left corner of the screen), scaled
+// down (or up, with --scale-up) to fit it, and the cursor is not moved.
+//
+// If the terminal doesn't support kitty's graphics protocol (or doesn't answer
+// the detection query), or with --text, images are rendered as text with
+// Unicode half blocks, scaled down to the width of the terminal (80 columns
+// if the output is not a terminal). The colors depend on COLORTERM and TERM.
+// --align and --place are not supported.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"image"
+	"io"
 	"os"
+	"regexp"
+	"strconv"
 
 	_ "image/gif"
 	_ "image/jpeg"
@@ -56,16 +92,116 @@ func main() {
 	os.Exit(status)
 }
 
+var transferModes = map[string]kittyimg.Medium{
+	"auto":   kittyimg.MediumAuto,
+	"stream": kittyimg.MediumDirect,
+	"file":   kittyimg.MediumFile,
+	"temp":   kittyimg.MediumTempFile,
+	"memory": kittyimg.MediumSharedMemory,
+}
+
+var alignments = map[string]kittyimg.Align{
+	"left":   kittyimg.AlignLeft,
+	"center": kittyimg.AlignCenter,
+	"right":  kittyimg.AlignRight,
+}
+
 func icatMain(out *os.File, args []string) error {
+	flags := flag.NewFlagSet("icat", flag.ContinueOnError)
+	transferMode := flags.String("transfer-mode", "auto", "how image data is sent: auto, stream, file, temp or memory")
+	fit := flags.Bool("fit", false, "scale down images larger than the terminal window")
+	scaleUp := flags.Bool("scale-up", false, "scale images up or down to fit the terminal window")
+	alignName := flags.String("align", "left", "horizontal alignment: left, center or right")
+	place := flags.String("place", "", "display the image in the `WxH@LxT` rectangle of cells")
+	text := flags.Bool("text", false, "render images as text with Unicode blocks")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+
+	medium, ok := transferModes[*transferMode]
+	if !ok {
+		return fmt.Errorf("icat: invalid transfer mode %q", *transferMode)
+	}
+	align, ok := alignments[*alignName]
+	if !ok {
+		return fmt.Errorf("icat: invalid alignment %q", *alignName)
+	}
+	var placeRect image.Rectangle
+	if *place != "" {
+		var err error
+		if placeRect, err = parsePlace(*place); err != nil {
+			return err
+		}
+		if len(args) > 1 {
+			return errors.New("icat: --place requires a single image")
+		}
+	}
+
+	if *text {
+		return textMain(out, args, *fit, *scaleUp, *place != "")
+	}
+
+	// Inside tmux or GNU screen, graphics commands are wrapped to pass
+	// through the multiplexer, but replies to queries are not forwarded back
+	var w io.Writer = out
+	var tty *os.File
+	// flush writes the bytes held back by the passthrough writer
+	flush := func() error { return nil }
+	if os.Getenv("TMUX") != "" || os.Getenv("STY") != "" {
+		w = kittyimg.NewPassthroughWriter(out)
+		if f, ok := w.(interface{ Flush() error }); ok {
+			flush = f.Flush
+			// On error
+			defer f.Flush()
+		}
+	} else {
+		var graphics bool
+		if tty, graphics = checkTerminal(out); !graphics {
+			return textMain(out, args, *fit, *scaleUp, *place != "")
+		}
+		if tty != nil {
+			defer tty.Close()
+		}
+	}
+
+	enc := kittyimg.Encoder{
+		Medium: medium,
+		TTY:    tty,
+		Align:  align,
+	}
+	newline := "\n"
+	if *fit || *scaleUp || align != kittyimg.AlignLeft || *place != "" {
+		ws, err := windowSize(tty)
+		if err != nil {
+			return err
+		}
+		enc.Window = ws
+		cell := ws.CellSize()
+		switch {
+		case *place != "":
+			enc.Placement = &kittyimg.Placement{At: &placeRect.Min}
+			enc.Fit = image.Pt(placeRect.Dx()*cell.X, placeRect.Dy()*cell.Y)
+			enc.ScaleUp = *scaleUp
+			// The cursor is restored
+			newline = ""
+		case *fit || *scaleUp:
+			// Keep one line for the prompt
+			enc.Fit = image.Pt(ws.Width, max(ws.Height-cell.Y, 1))
+			enc.ScaleUp = *scaleUp
+		}
+	}
+
 	if (len(args) == 0 || args[0] == "-") && !term.IsTerminal(int(os.Stdin.Fd())) {
-		if err := kittyimg.Transcode(out, os.Stdin); err != nil {
+		if err := enc.Transcode(w, os.Stdin); err != nil {
 			return err
 		}
-		out.WriteString("\n")
-		return nil
+		if _, err := io.WriteString(w, newline); err != nil {
+			return err
+		}
+		return flush()
 	}
 
-	var enc kittyimg.Encoder
 	for _, file := range args {
 		err := (func(file string) error {
 			f, err := os.Open(file)
@@ -74,13 +210,134 @@ func icatMain(out *os.File, args []string) error {
 			}
 			defer f.Close()
 
-			return enc.Transcode(out, f)
+			return enc.Transcode(w, f)
 		})(file)
 		if err != nil {
 			return err
 		}
-		out.WriteString("\n")
+		if _, err = io.WriteString(w, newline); err != nil {
+			return err
+		}
 	}
 
+	return flush()
+}
+
+// checkTerminal checks whether the terminal supports kitty's graphics protocol
+// if out is a terminal, and returns the controlling terminal. The check is
+// skipped (graphics is true and tty is nil) if out is not a terminal or if
+// the controlling terminal is not available. A terminal that fails to answer
+// the detection (timeout) is considered as not supporting graphics.
+func checkTerminal(out *os.File) (tty *os.File, graphics bool) {
+	if !term.IsTerminal(int(out.Fd())) {
+		return nil, true
+	}
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, true
+	}
+	graphics, err = kittyimg.Detect(context.Background(), tty)
+	if err != nil || !graphics {
+		tty.Close()
+		return nil, false
+	}
+	return tty, true
+}
+
+// textMain renders the image files as text.
+func textMain(out *os.File, args []string, fit, scaleUp, place bool) error {
+	if place {
+		return errors.New("icat: --place is not supported with text rendering")
+	}
+
+	enc := kittyimg.TextEncoder{
+		Colors:  kittyimg.DetectTextColors(),
+		Fit:     image.Pt(80, 0),
+		ScaleUp: scaleUp,
+	}
+	if cols, rows, err := term.GetSize(int(out.Fd())); err == nil {
+		enc.Fit.X = cols
+		if fit || scaleUp {
+			// Keep one line for the prompt
+			enc.Fit.Y = max(rows-1, 1)
+		}
+	}
+
+	display := func(r io.Reader) error {
+		// Only the first frame of animations is shown
+		img, _, err := image.Decode(r)
+		if err != nil {
+			if r, ok := r.(*os.File); ok {
+				return fmt.Errorf("%s: %w", r.Name(), err)
+			}
+			return err
+		}
+		if err = enc.Encode(out, img); err != nil {
+			return err
+		}
+		_, err = out.WriteString("\n")
+		return err
+	}
+
+	if (len(args) == 0 || args[0] == "-") && !term.IsTerminal(int(os.Stdin.Fd())) {
+		return display(os.Stdin)
+	}
+	for _, file := range args {
+		err := (func(file string) error {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			return display(f)
+		})(file)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+var placeRE = regexp.MustCompile(`^([0-9]+)x([0-9]+)@([0-9]+)x([0-9]+)$`)
+
+// parsePlace parses the value of --place, WxH@LxT, as a rectangle of cells.
+func parsePlace(s string) (image.Rectangle, error) {
+	m := placeRE.FindStringSubmatch(s)
+	if m == nil {
+		return image.Rectangle{}, fmt.Errorf("icat: invalid --place %q: WxH@LxT expected", s)
+	}
+	var n [4]int
+	for i := range n {
+		var err error
+		if n[i], err = strconv.Atoi(m[i+1]); err != nil {
+			return image.Rectangle{}, fmt.Errorf("icat: invalid --place %q: %w", s, err)
+		}
+	}
+	r := image.Rect(n[2], n[3], n[2]+n[0], n[3]+n[1])
+	if r.Empty() {
+		return image.Rectangle{}, fmt.Errorf("icat: invalid --place %q: empty rectangle", s)
+	}
+	return r, nil
+}
+
+// windowSize returns the size of the terminal window, which must be known
+// in pixels. The controlling terminal is opened if tty is nil.
+func windowSize(tty *os.File) (*kittyimg.WindowSize, error) {
+	if tty == nil {
+		f, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("icat: window size: %w", err)
+		}
+		defer f.Close()
+		tty = f
+	}
+	ws, err := kittyimg.GetWindowSize(context.Background(), tty)
+	if err != nil {
+		return nil, fmt.Errorf("icat: window size: %w", err)
+	}
+	if ws.Width <= 0 || ws.Height <= 0 {
+		return nil, errors.New("icat: unknown window size in pixels")
+	}
+	return ws, nil
+}
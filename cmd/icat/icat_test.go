@@ -17,6 +17,7 @@
 package main
 
 import (
+	"image"
 	"io"
 	"os"
 	"strings"
@@ -68,3 +69,38 @@ func Test(t *testing.T) {
 		t.Error("unexpected output")
 	}
 }
+
+func TestParsePlace(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected image.Rectangle
+	}{
+		{"40x20@0x0", image.Rect(0, 0, 40, 20)},
+		{"10x5@3x7", image.Rect(3, 7, 13, 12)},
+		{"10x5", image.Rectangle{}},
+		{"0x5@1x1", image.Rectangle{}},
+		{"-1x5@1x1", image.Rectangle{}},
+		{"10x5@1x1x", image.Rectangle{}},
+	} {
+		r, err := parsePlace(tc.in)
+		if r != tc.expected || (err == nil) != !tc.expected.Empty() {
+			t.Errorf("%q: got %v, %v", tc.in, r, err)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	t.Setenv("COLORTERM", "truecolor")
+	out, err := runMain(t, "icat --text dolmen.gif", "--text", "../../dolmen.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out, "\x1b_G") {
+		t.Error("unexpected graphics command")
+	}
+	// 420x66 scaled to 80 columns of 1×2 pixels
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 7 || !strings.Contains(out, "\x1b[38;2;") || !strings.HasSuffix(out, "\x1b[m\n") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import "strconv"
+
+// Escape sequences delimiting a graphics command.
+// https://sw.kovidgoyal.net/kitty/graphics-protocol/#the-graphics-escape-code
+const (
+	apcStart = "\033_G"
+	apcEnd   = "\033\\"
+)
+
+// appendCommand starts the control data of a graphics command with the given action.
+// Responses from the terminal are limited to errors (q=1).
+// id is the image ID (i=), omitted if 0.
+// https://sw.kovidgoyal.net/kitty/graphics-protocol/#control-data-reference
+func appendCommand(b []byte, action byte, id uint32) []byte {
+	b = append(b, apcStart+"q=1,a="...)
+	b = append(b, action)
+	if id != 0 {
+		b = appendKeyUint(b, 'i', id)
+	}
+	return b
+}
+
+// appendKeyInt appends ",key=value" to the control data.
+func appendKeyInt(b []byte, key byte, value int) []byte {
+	b = append(b, ',', key, '=')
+	return strconv.AppendInt(b, int64(value), 10)
+}
+
+// appendKeyUint appends ",key=value" to the control data.
+func appendKeyUint(b []byte, key byte, value uint32) []byte {
+	b = append(b, ',', key, '=')
+	return strconv.AppendUint(b, uint64(value), 10)
+}
+
+// appendKeyChar appends ",key=value" to the control data.
+func appendKeyChar(b []byte, key byte, value byte) []byte {
+	return append(b, ',', key, '=', value)
+}
+
+// appendPlacement appends the placement keys of p to the control data.
+func appendPlacement(b []byte, p *Placement) []byte {
+	if p == nil {
+		return b
+	}
+	if p.ID != 0 {
+		b = appendKeyUint(b, 'p', p.ID)
+	}
+	if !p.Source.Empty() {
+		b = appendKeyInt(b, 'x', p.Source.Min.X)
+		b = appendKeyInt(b, 'y', p.Source.Min.Y)
+		b = appendKeyInt(b, 'w', p.Source.Dx())
+		b = appendKeyInt(b, 'h', p.Source.Dy())
+	}
+	if p.XOffset != 0 {
+		b = appendKeyInt(b, 'X', p.XOffset)
+	}
+	if p.YOffset != 0 {
+		b = appendKeyInt(b, 'Y', p.YOffset)
+	}
+	if p.Columns != 0 {
+		b = appendKeyInt(b, 'c', p.Columns)
+	}
+	if p.Rows != 0 {
+		b = appendKeyInt(b, 'r', p.Rows)
+	}
+	if p.Z != 0 {
+		b = appendKeyInt(b, 'z', int(p.Z))
+	}
+	if p.NoMove || (p.At != nil && !p.Virtual) {
+		b = appendKeyInt(b, 'C', 1)
+	}
+	if p.Virtual {
+		b = appendKeyInt(b, 'U', 1)
+	}
+	if p.ParentID != 0 {
+		b = appendKeyUint(b, 'P', p.ParentID)
+		if p.ParentPlacementID != 0 {
+			b = appendKeyUint(b, 'Q', p.ParentPlacementID)
+		}
+		if p.ParentOffset.X != 0 {
+			b = appendKeyInt(b, 'H', p.ParentOffset.X)
+		}
+		if p.ParentOffset.Y != 0 {
+			b = appendKeyInt(b, 'V', p.ParentOffset.Y)
+		}
+	}
+	return b
+}
@@ -0,0 +1,160 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import "io"
+
+// Delete is a [delete command] (a=d) that removes images or placements from
+// the screen. Use one of the Delete* functions to build a Delete, then call
+// [Delete.WriteTo] to send it to the terminal.
+//
+// By default the image data is kept by the terminal, so the image can be
+// displayed again (see [Image.Place]). Use [Delete.Free] to also free the
+// image data.
+//
+// [delete command]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#deleting-images
+type Delete struct {
+	d      byte   // d=
+	id     uint32 // i=
+	number uint32 // I=
+	pid    uint32 // p=
+	x, y   int    // x=, y=
+	z      int32  // z=
+}
+
+// DeleteAll deletes all placements visible on screen (d=a).
+func DeleteAll() Delete {
+	return Delete{d: 'a'}
+}
+
+// DeleteImage deletes all placements of the image with the given ID (d=i).
+func DeleteImage(id uint32) Delete {
+	return Delete{d: 'i', id: id}
+}
+
+// DeletePlacement deletes the placement with the given ID of the image with
+// the given ID (d=i).
+func DeletePlacement(id, placementID uint32) Delete {
+	return Delete{d: 'i', id: id, pid: placementID}
+}
+
+// DeleteNumber deletes all placements of the newest image with the given image
+// number (d=n).
+func DeleteNumber(number uint32) Delete {
+	return Delete{d: 'n', number: number}
+}
+
+// DeleteNumberPlacement deletes the placement with the given ID of the newest
+// image with the given image number (d=n).
+func DeleteNumberPlacement(number, placementID uint32) Delete {
+	return Delete{d: 'n', number: number, pid: placementID}
+}
+
+// DeleteAtCursor deletes all placements that intersect the cell at the
+// cursor position (d=c).
+func DeleteAtCursor() Delete {
+	return Delete{d: 'c'}
+}
+
+// DeleteAtCell deletes all placements that intersect the given cell (d=p).
+// Column and row numbers start at 1.
+func DeleteAtCell(column, row int) Delete {
+	return Delete{d: 'p', x: column, y: row}
+}
+
+// DeleteAtCellZ deletes all placements with the given z-index that intersect
+// the given cell (d=q). Column and row numbers start at 1.
+func DeleteAtCellZ(column, row int, z int32) Delete {
+	return Delete{d: 'q', x: column, y: row, z: z}
+}
+
+// DeleteInColumn deletes all placements that intersect the given column (d=x).
+// Column numbers start at 1.
+func DeleteInColumn(column int) Delete {
+	return Delete{d: 'x', x: column}
+}
+
+// DeleteInRow deletes all placements that intersect the given row (d=y).
+// Row numbers start at 1.
+func DeleteInRow(row int) Delete {
+	return Delete{d: 'y', y: row}
+}
+
+// DeleteZ deletes all placements with the given z-index (d=z).
+func DeleteZ(z int32) Delete {
+	return Delete{d: 'z', z: z}
+}
+
+// DeleteIDRange deletes all images with an ID between first and last,
+// inclusive (d=r).
+func DeleteIDRange(first, last uint32) Delete {
+	return Delete{d: 'r', x: int(first), y: int(last)}
+}
+
+// DeleteFrames deletes the [animation frames] of the image with the given ID (d=f).
+//
+// [animation frames]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#deleting-frames
+func DeleteFrames(id uint32) Delete {
+	return Delete{d: 'f', id: id}
+}
+
+// Free returns a copy of d that also frees the image data stored by the
+// terminal when the image is no longer referenced by any placement (uppercase
+// d= value).
+func (d Delete) Free() Delete {
+	if 'a' <= d.d && d.d <= 'z' {
+		d.d -= 'a' - 'A'
+	}
+	return d
+}
+
+// WriteTo writes the delete command to w. It implements [io.WriterTo].
+func (d Delete) WriteTo(w io.Writer) (int64, error) {
+	cmd := appendCommand(make([]byte, 0, 64), 'd', d.id)
+	cmd = appendKeyChar(cmd, 'd', d.d)
+	if d.number != 0 {
+		cmd = appendKeyUint(cmd, 'I', d.number)
+	}
+	if d.pid != 0 {
+		cmd = appendKeyUint(cmd, 'p', d.pid)
+	}
+	switch d.d | 0x20 { // lowercase
+	case 'p', 'q':
+		cmd = appendKeyInt(cmd, 'x', d.x)
+		cmd = appendKeyInt(cmd, 'y', d.y)
+	case 'r':
+		cmd = appendKeyUint(cmd, 'x', uint32(d.x))
+		cmd = appendKeyUint(cmd, 'y', uint32(d.y))
+	case 'x':
+		cmd = appendKeyInt(cmd, 'x', d.x)
+	case 'y':
+		cmd = appendKeyInt(cmd, 'y', d.y)
+	}
+	switch d.d | 0x20 {
+	case 'q', 'z':
+		cmd = appendKeyInt(cmd, 'z', int(d.z))
+	}
+	n, err := w.Write(append(cmd, ";"+apcEnd...))
+	return int64(n), err
+}
+
+// Delete removes all placements of the image from the screen and frees the
+// image data stored by the terminal (a=d,d=I).
+func (img *Image) Delete(w io.Writer) error {
+	_, err := DeleteImage(img.ID).Free().WriteTo(w)
+	return err
+}
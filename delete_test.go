@@ -0,0 +1,85 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func ExampleDelete() {
+	// Remove all images from the screen and free their data
+	kittyimg.DeleteAll().Free().WriteTo(os.Stdout)
+}
+
+func TestDelete(t *testing.T) {
+	for _, tc := range []struct {
+		del      kittyimg.Delete
+		expected string // control data, for the lowercase variant
+	}{
+		{kittyimg.DeleteAll(), "q=1,a=d,d=a"},
+		{kittyimg.DeleteImage(5), "q=1,a=d,i=5,d=i"},
+		{kittyimg.DeletePlacement(5, 2), "q=1,a=d,i=5,d=i,p=2"},
+		{kittyimg.DeleteNumber(9), "q=1,a=d,d=n,I=9"},
+		{kittyimg.DeleteNumberPlacement(9, 2), "q=1,a=d,d=n,I=9,p=2"},
+		{kittyimg.DeleteAtCursor(), "q=1,a=d,d=c"},
+		{kittyimg.DeleteAtCell(3, 4), "q=1,a=d,d=p,x=3,y=4"},
+		{kittyimg.DeleteAtCellZ(3, 4, -1), "q=1,a=d,d=q,x=3,y=4,z=-1"},
+		{kittyimg.DeleteAtCellZ(3, 4, 0), "q=1,a=d,d=q,x=3,y=4,z=0"},
+		{kittyimg.DeleteInColumn(7), "q=1,a=d,d=x,x=7"},
+		{kittyimg.DeleteInRow(8), "q=1,a=d,d=y,y=8"},
+		{kittyimg.DeleteZ(0), "q=1,a=d,d=z,z=0"},
+		{kittyimg.DeleteIDRange(10, 4294967295), "q=1,a=d,d=r,x=10,y=4294967295"},
+		{kittyimg.DeleteFrames(6), "q=1,a=d,i=6,d=f"},
+	} {
+		for _, free := range []bool{false, true} {
+			del, expected := tc.del, tc.expected
+			if free {
+				del = del.Free()
+				i := strings.Index(expected, ",d=") + 3
+				expected = expected[:i] + strings.ToUpper(expected[i:i+1]) + expected[i+1:]
+			}
+			expected = "\033_G" + expected + ";\033\\"
+
+			var sb strings.Builder
+			n, err := del.WriteTo(&sb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sb.String(); got != expected {
+				t.Errorf("got %q, expected %q", got, expected)
+			}
+			if n != int64(sb.Len()) {
+				t.Errorf("%q: n=%d", expected, n)
+			}
+		}
+	}
+}
+
+func TestImageDelete(t *testing.T) {
+	img := kittyimg.Image{ID: 3}
+	var sb strings.Builder
+	if err := img.Delete(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := sb.String(), "\033_Gq=1,a=d,i=3,d=I;\033\\"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
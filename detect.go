@@ -0,0 +1,169 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"regexp"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// DetectTimeout is the timeout applied by [Detect] and [GetWindowSize] if ctx
+// has no deadline.
+const DetectTimeout = 2 * time.Second
+
+// queryDirect is a query (a=q) of a 1x1 RGB image sent with direct transmission.
+// https://sw.kovidgoyal.net/kitty/graphics-protocol/#querying-support-and-available-transmission-mediums
+const queryDirect = apcStart + "a=q,i=31,s=1,v=1,t=d,f=24;AAAA" + apcEnd
+
+// primaryDeviceAttributes is the DA1 request, which all terminals answer.
+// Receiving the answer means that no reply to the query will come.
+const primaryDeviceAttributes = "\033[c"
+
+var daReplyRE = regexp.MustCompile("\033\\[\\?[0-9;]*c")
+
+// Detect reports whether the terminal tty supports the graphics protocol.
+//
+// The terminal is switched to raw mode while a query (a=q) followed by a
+// primary device attributes request (DA1) is sent. The graphics protocol is
+// supported if a reply to the query is received before the reply to DA1.
+// If tty is not a terminal, Detect returns false.
+//
+// If ctx has no deadline, [DetectTimeout] is applied. If the timeout expires
+// (the terminal doesn't even reply to DA1), ctx.Err() is returned.
+// Cancellation relies on [os.File.SetReadDeadline], so tty should be a
+// pollable file such as one opened from /dev/tty.
+func Detect(ctx context.Context, tty *os.File) (bool, error) {
+	resp, err := query(ctx, tty, []byte(queryDirect))
+	if err == errNotTerminal {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return resp != nil, nil
+}
+
+// errNotTerminal is returned by query if tty is not a terminal.
+var errNotTerminal = errors.New("kittyimg: not a terminal")
+
+// query sends the query command cmd (a=q) to the terminal followed by DA1 and
+// returns the response to cmd, or nil if the terminal replied only to DA1.
+func query(ctx context.Context, tty *os.File, cmd []byte) (*Response, error) {
+	b, _, err := exchange(ctx, tty, cmd)
+	if err != nil {
+		return nil, err
+	}
+	return findResponse(b)
+}
+
+// terminalFd returns the file descriptor of tty.
+func terminalFd(tty *os.File) (int, error) {
+	// Do not use tty.Fd() as it switches the file to blocking mode,
+	// which disables SetReadDeadline.
+	rawConn, err := tty.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+	fd := -1
+	if err = rawConn.Control(func(f uintptr) { fd = int(f) }); err != nil {
+		return -1, err
+	}
+	return fd, nil
+}
+
+// exchange sends req to the terminal followed by DA1 and returns the input
+// received before the reply to DA1, and the reply to DA1.
+func exchange(ctx context.Context, tty *os.File, req []byte) (b, da []byte, err error) {
+	fd, err := terminalFd(tty)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !term.IsTerminal(fd) {
+		// Detection is pointless as escape sequences would be lost
+		return nil, nil, errNotTerminal
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer term.Restore(fd, state)
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DetectTimeout)
+		defer cancel()
+	}
+
+	if _, err = tty.Write(append(req, primaryDeviceAttributes...)); err != nil {
+		return nil, nil, err
+	}
+
+	type result struct {
+		b, da []byte
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var buf []byte
+		b := make([]byte, 256)
+		for {
+			n, err := tty.Read(b)
+			buf = append(buf, b[:n]...)
+			if loc := daReplyRE.FindIndex(buf); loc != nil {
+				done <- result{buf[:loc[0]], buf[loc[0]:loc[1]], nil}
+				return
+			}
+			if err != nil {
+				done <- result{nil, nil, err}
+				return
+			}
+		}
+	}()
+
+	select {
+	case res := <-done:
+		return res.b, res.da, res.err
+	case <-ctx.Done():
+		// Interrupt the reading goroutine
+		if tty.SetReadDeadline(time.Now()) == nil {
+			<-done
+			_ = tty.SetReadDeadline(time.Time{})
+		}
+		return nil, nil, ctx.Err()
+	}
+}
+
+// findResponse extracts the graphics protocol response from the terminal input.
+func findResponse(b []byte) (*Response, error) {
+	i := bytes.Index(b, []byte(apcStart))
+	if i < 0 {
+		return nil, nil
+	}
+	b = b[i+len(apcStart):]
+	i = bytes.Index(b, []byte(apcEnd))
+	if i < 0 {
+		return nil, ErrBadResponse
+	}
+	return parseResponse(b[:i])
+}
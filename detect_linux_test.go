@@ -0,0 +1,140 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// openPTY opens a pseudo-terminal pair.
+// The terminal emulator side is master, the application side is tty.
+func openPTY(t *testing.T) (master, tty *os.File) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("pty:", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	rawConn, err := master.SyscallConn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	err = rawConn.Control(func(fd uintptr) {
+		if err = unix.IoctlSetPointerInt(int(fd), unix.TIOCSPTLCK, 0); err != nil {
+			return
+		}
+		n, err = unix.IoctlGetInt(int(fd), unix.TIOCGPTN)
+	})
+	if err != nil {
+		t.Fatal("pty:", err)
+	}
+
+	tty, err = os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal("pty:", err)
+	}
+	t.Cleanup(func() { tty.Close() })
+	return master, tty
+}
+
+// fakeTerminal reads from master until the DA1 request is received, then writes reply.
+func fakeTerminal(t *testing.T, master *os.File, reply string) <-chan []byte {
+	received := make(chan []byte, 1)
+	go func() {
+		var buf []byte
+		b := make([]byte, 256)
+		for !bytes.HasSuffix(buf, []byte("\033[c")) {
+			n, err := master.Read(b)
+			buf = append(buf, b[:n]...)
+			if err != nil {
+				t.Error(err)
+				break
+			}
+		}
+		if reply != "" {
+			master.WriteString(reply)
+		}
+		received <- buf
+	}()
+	return received
+}
+
+func TestDetect(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reply    string
+		expected bool
+	}{
+		{"kitty", "\033_Gi=31;OK\033\\\033[?62;c", true},
+		{"xterm", "\033[?1;2c", false},
+		{"error", "\033_Gi=31;EINVAL:bad\033\\\033[?62;22c", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			master, tty := openPTY(t)
+			received := fakeTerminal(t, master, tc.reply)
+
+			ok, err := kittyimg.Detect(context.Background(), tty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tc.expected {
+				t.Errorf("got %t, expected %t", ok, tc.expected)
+			}
+			q := <-received
+			t.Logf("%q", q)
+			if !bytes.Contains(q, []byte("a=q,")) {
+				t.Error("query not sent")
+			}
+		})
+	}
+}
+
+func TestDetectTimeout(t *testing.T) {
+	master, tty := openPTY(t)
+	_ = fakeTerminal(t, master, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	ok, err := kittyimg.Detect(ctx, tty)
+	if ok || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %t, %v", ok, err)
+	}
+}
+
+func TestDetectNotTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	ok, err := kittyimg.Detect(context.Background(), w)
+	if ok || err != nil {
+		t.Errorf("got %t, %v", ok, err)
+	}
+}
@@ -20,9 +20,11 @@ package kittyimg_test
 
 import (
 	"bytes"
+	"compress/zlib"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"maps"
 	"os"
@@ -180,6 +182,30 @@ func extractBlocks(s []byte) iter.Seq[*Block] {
 	}
 }
 
+// decodeImage returns the control data of the first block and the (uncompressed)
+// payload of an image transmitted in one or more blocks.
+func decodeImage(t *testing.T, out []byte) (Params, []byte) {
+	t.Helper()
+	var params Params
+	var payload []byte
+	for bl := range extractBlocks(out) {
+		if params == nil {
+			params = bl.Params
+		}
+		payload = append(payload, bl.Payload...)
+	}
+	if params['o'] == "z" {
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payload, err = io.ReadAll(zr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return params, payload
+}
+
 func testDecode(t *testing.T, filepath string, expectedLen int) {
 	t.Parallel()
 
@@ -97,7 +97,7 @@ func captureExampleOutput(t *testing.T, name string, example func()) string {
 func TestExample(t *testing.T) {
 	out := captureExampleOutput(t, "Example", Example)
 	t.Log(out)
-	if !strings.HasPrefix(out, "\x1b_Gq=1,a=T,f=32,s=16,v=15,t=d,o=z;eJz6+//Pfxi29A") {
+	if !strings.HasPrefix(out, "\x1b_Gq=1,a=T,f=24,s=16,v=15,t=d,o=z;eJz6+/8PBFn6BuFBcGVw") {
 		t.Fatalf("unexpected output: %q", out)
 	}
 }
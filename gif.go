@@ -0,0 +1,148 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// encodeGIF transmits the GIF image g. A GIF with multiple frames is
+// transmitted as an [animation].
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
+func (enc *Encoder) encodeGIF(w io.Writer, g *gif.GIF, action byte, id uint32) error {
+	if len(g.Image) == 1 {
+		return enc.encode(w, g.Image[0], action, id)
+	}
+
+	size := image.Pt(g.Config.Width, g.Config.Height)
+	return enc.encodeCanvas(w, size, len(g.Image), func(i int) (canvasFrame, error) {
+		return canvasFrame{
+			img:      g.Image[i],
+			bounds:   g.Image[i].Bounds(),
+			op:       draw.Over,
+			disposal: g.Disposal[i],
+			delay:    gifDelay(g.Delay[i]),
+		}, nil
+	}, gifLoops(g.LoopCount), action, id)
+}
+
+// canvasFrame is a frame of an animation file, to draw on the canvas.
+type canvasFrame struct {
+	img      image.Image
+	bounds   image.Rectangle // location of img on the canvas
+	op       draw.Op         // draw.Over to blend img with the canvas, draw.Src to replace pixels
+	disposal byte            // gif.Disposal* value
+	delay    int             // frame gap in milliseconds
+}
+
+// encodeCanvas transmits as an [animation] n frames composed on a canvas of
+// the given size. frame returns the frame number i (0 based).
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
+func (enc *Encoder) encodeCanvas(w io.Writer, size image.Point, n int, frame func(i int) (canvasFrame, error), loops int, action byte, id uint32) error {
+	// Frames must refer to the image
+	if id == 0 {
+		id = randomID()
+	}
+
+	// Frames are composed in canvas, following the disposal method of each frame.
+	// Then for each new frame, the area changed from the previous frame is sent to
+	// replace pixels (X=1) over the previous frame (c=).
+	// If the canvas must be resized, the changed area is computed on the
+	// resized canvas.
+	fit := enc.fitSize(size)
+	canvas := image.NewRGBA(image.Rectangle{Max: size})
+	var saved *image.RGBA   // for gif.DisposalPrevious
+	var resized *image.RGBA // the previous resized canvas
+	var disposed image.Rectangle
+	for i := 0; i < n; i++ {
+		f, err := frame(i)
+		if err != nil {
+			return err
+		}
+		bounds := f.bounds.Intersect(canvas.Rect)
+		if f.disposal == gif.DisposalPrevious {
+			saved = image.NewRGBA(bounds)
+			draw.Draw(saved, bounds, canvas, bounds.Min, draw.Src)
+		}
+		draw.Draw(canvas, bounds, f.img, f.img.Bounds().Min.Add(bounds.Min.Sub(f.bounds.Min)), f.op)
+
+		img, changed := canvas, bounds.Union(disposed)
+		if fit != size {
+			img = scale(canvas, fit)
+			if resized != nil {
+				changed = diffRect(resized, img)
+			}
+			resized = img
+		}
+
+		if i == 0 {
+			if err = enc.encode(w, img, action, id); err != nil {
+				return err
+			}
+			if err = setFrameGap(w, id, 1, f.delay); err != nil {
+				return err
+			}
+		} else {
+			if changed.Empty() {
+				changed = image.Rect(0, 0, 1, 1)
+			}
+			if err = enc.encodeFrame(w, id, img.SubImage(changed), changed.Min, i, true, f.delay); err != nil {
+				return err
+			}
+		}
+
+		// Dispose the frame before the next one
+		switch f.disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, bounds, image.Transparent, image.Point{}, draw.Src)
+			disposed = bounds
+		case gif.DisposalPrevious:
+			draw.Draw(canvas, bounds, saved, bounds.Min, draw.Src)
+			disposed = bounds
+		default:
+			disposed = image.Rectangle{}
+		}
+	}
+
+	return runAnimation(w, id, loops)
+}
+
+// gifDelay converts a GIF frame delay (in 1/100 s) to a frame gap in milliseconds.
+func gifDelay(delay int) int {
+	if delay <= 1 {
+		// Like browsers do
+		return 100
+	}
+	return delay * 10
+}
+
+// gifLoops converts a GIF loop count to the number of times the animation is
+// played (0 for forever).
+func gifLoops(loopCount int) int {
+	if loopCount < 0 { // Play once
+		return 1
+	}
+	if loopCount == 0 { // Forever
+		return 0
+	}
+	return loopCount + 1
+}
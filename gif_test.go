@@ -0,0 +1,151 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+
+	_ "image/gif"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// inflate decompresses the payload of a block sent with o=z.
+func inflate(t *testing.T, bl *Block) []byte {
+	t.Helper()
+	zr, err := zlib.NewReader(bytes.NewReader(bl.Payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pix
+}
+
+// checkFrame checks the RGBA pixels of a frame block against expected, which
+// gives the color of each pixel of the canvas.
+func checkFrame(t *testing.T, bl *Block, expected func(x, y int) color.NRGBA) {
+	t.Helper()
+	atoi := func(k byte) int {
+		n, _ := strconv.Atoi(bl.Params[k])
+		return n
+	}
+	x0, y0, w, h := atoi('x'), atoi('y'), atoi('s'), atoi('v')
+	if bl.Params['f'] != "32" {
+		t.Fatalf("f=%s", bl.Params['f'])
+	}
+	pix := inflate(t, bl)
+	if len(pix) != w*h*4 {
+		t.Fatalf("got %d bytes, expected %d", len(pix), w*h*4)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := (y*w + x) * 4
+			got := color.NRGBA{pix[i], pix[i+1], pix[i+2], pix[i+3]}
+			if exp := expected(x0+x, y0+y); got != exp {
+				t.Fatalf("pixel (%d, %d): got %v, expected %v", x0+x, y0+y, got, exp)
+			}
+		}
+	}
+}
+
+func TestTranscodeAnimatedGIF(t *testing.T) {
+	f, err := os.Open("testdata/spinner.gif")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if err = enc.Transcode(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+
+	var blocks []*Block
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		blocks = append(blocks, bl)
+	}
+	if len(blocks) != 6 {
+		t.Fatalf("got %d blocks, expected 6", len(blocks))
+	}
+
+	id := blocks[0].Params['i']
+	if id == "" {
+		t.Fatal("missing image ID")
+	}
+	for i, expected := range []string{
+		"a=T,f=32,i=" + id + ",o=z,q=1,s=16,t=d,v=16",
+		"a=a,i=" + id + ",q=1,r=1,z=100",
+		"X=1,a=f,c=1,f=32,i=" + id + ",o=z,q=1,s=8,t=d,v=8,z=200",
+		"X=1,a=f,c=2,f=32,i=" + id + ",o=z,q=1,s=16,t=d,v=8,z=300",
+		"X=1,a=f,c=3,f=32,i=" + id + ",o=z,q=1,s=16,t=d,v=16,z=400",
+		"a=a,i=" + id + ",q=1,s=3,v=1",
+	} {
+		if got := blocks[i].Params.String(); got != expected {
+			t.Errorf("block %d: got %q, expected %q", i, got, expected)
+		}
+	}
+
+	var (
+		transparent = color.NRGBA{}
+		blue        = color.NRGBA{0x00, 0xad, 0xd8, 0xff}
+		yellow      = color.NRGBA{0xfd, 0xdd, 0x00, 0xff}
+		fuchsia     = color.NRGBA{0xce, 0x32, 0x62, 0xff}
+	)
+	checker := func(c color.NRGBA) func(x, y int) color.NRGBA {
+		return func(x, y int) color.NRGBA {
+			if (x+y)%2 == 0 {
+				return c
+			}
+			return transparent
+		}
+	}
+	topLeft := func(x, y int) bool { return x < 8 && y < 8 }
+	topRight := func(x, y int) bool { return x >= 8 && y < 8 }
+
+	checkFrame(t, blocks[0], checker(blue))
+	checkFrame(t, blocks[2], checker(yellow))
+	// Top-left disposed to background
+	checkFrame(t, blocks[3], func(x, y int) color.NRGBA {
+		if topLeft(x, y) {
+			return transparent
+		}
+		return checker(fuchsia)(x, y)
+	})
+	// Top-right restored to previous
+	checkFrame(t, blocks[4], func(x, y int) color.NRGBA {
+		switch {
+		case topLeft(x, y):
+			return transparent
+		case topRight(x, y):
+			return checker(blue)(x, y)
+		}
+		return checker(yellow)(x, y)
+	})
+}
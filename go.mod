@@ -2,6 +2,8 @@ module github.com/dolmen-go/kittyimg
 
 go 1.21
 
-require golang.org/x/term v0.29.0
-
-require golang.org/x/sys v0.30.0 // indirect
+require (
+	golang.org/x/image v0.24.0
+	golang.org/x/sys v0.30.0
+	golang.org/x/term v0.29.0
+)
@@ -0,0 +1,158 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+)
+
+// ITermEncoder displays images with the [inline images protocol] of iTerm2
+// (OSC 1337), also supported by WezTerm and VS Code's terminal.
+//
+// [inline images protocol]: https://iterm2.com/documentation-images.html
+type ITermEncoder struct {
+	// Width and Height are the size of the displayed image. The default,
+	// ITermAuto, is the size of the image.
+	Width, Height ITermSize
+
+	// Stretch disables preserving the aspect ratio of the image
+	// (preserveAspectRatio=0) when both Width and Height are set.
+	Stretch bool
+
+	// Name is the file name of the image, which the terminal may show.
+	Name string
+
+	// Multipart sends the file in several escape codes (MultipartFile,
+	// FilePart, FileEnd), which terminals handle better for large files.
+	// Supported since iTerm2 3.5.
+	Multipart bool
+
+	buf []byte
+}
+
+// ITermSize is a dimension of an image displayed with [ITermEncoder]:
+// a number of cells, of pixels, or a percentage of the terminal window.
+type ITermSize string
+
+// ITermAuto is the size of the image.
+const ITermAuto ITermSize = ""
+
+// ITermCells returns the size of n cells.
+func ITermCells(n int) ITermSize {
+	return ITermSize(strconv.Itoa(n))
+}
+
+// ITermPixels returns the size of n pixels.
+func ITermPixels(n int) ITermSize {
+	return ITermSize(strconv.Itoa(n) + "px")
+}
+
+// ITermPercent returns the size of n percent of the terminal window.
+func ITermPercent(n int) ITermSize {
+	return ITermSize(strconv.Itoa(n) + "%")
+}
+
+// iTermChunkSize is the size of the base64 payload of each FilePart.
+const iTermChunkSize = 4096
+
+// Encode displays img at the cursor position. The image is sent as PNG.
+func (enc *ITermEncoder) Encode(w io.Writer, img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+	return enc.write(w, buf.Bytes())
+}
+
+// Transcode displays the image file read from r at the cursor position.
+//
+// PNG, JPEG and GIF files are sent unchanged, as the terminal decodes them.
+// Other formats registered with the [image] framework are converted to PNG.
+func (enc *ITermEncoder) Transcode(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return readError(r, err)
+	}
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return readError(r, err)
+	}
+	switch format {
+	case "png", "jpeg", "gif":
+		return enc.write(w, data)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return readError(r, err)
+	}
+	return enc.Encode(w, img)
+}
+
+// write sends the image file data.
+func (enc *ITermEncoder) write(w io.Writer, data []byte) error {
+	b := append(enc.buf[:0], "\033]1337;"...)
+	if enc.Multipart {
+		b = append(b, "MultipartFile="...)
+	} else {
+		b = append(b, "File="...)
+	}
+	if enc.Name != "" {
+		b = append(b, "name="...)
+		b = append(b, base64.StdEncoding.EncodeToString([]byte(enc.Name))...)
+		b = append(b, ';')
+	}
+	b = append(b, "size="...)
+	b = strconv.AppendInt(b, int64(len(data)), 10)
+	if enc.Width != ITermAuto {
+		b = append(b, ";width="...)
+		b = append(b, enc.Width...)
+	}
+	if enc.Height != ITermAuto {
+		b = append(b, ";height="...)
+		b = append(b, enc.Height...)
+	}
+	if enc.Stretch {
+		b = append(b, ";preserveAspectRatio=0"...)
+	}
+	b = append(b, ";inline=1"...)
+
+	if !enc.Multipart {
+		b = append(b, ':')
+		b = append(b, base64.StdEncoding.EncodeToString(data)...)
+		b = append(b, '\a')
+	} else {
+		b = append(b, '\a')
+		payload := base64.StdEncoding.EncodeToString(data)
+		for len(payload) > 0 {
+			n := min(len(payload), iTermChunkSize)
+			b = append(b, "\033]1337;FilePart="...)
+			b = append(b, payload[:n]...)
+			b = append(b, '\a')
+			payload = payload[n:]
+		}
+		b = append(b, "\033]1337;FileEnd\a"...)
+	}
+	enc.buf = b
+
+	_, err := w.Write(b)
+	return err
+}
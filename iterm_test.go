@@ -0,0 +1,187 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+var iTermFileRE = regexp.MustCompile(`^\033\]1337;File=([^:\a]*):([A-Za-z0-9+/=]*)\a$`)
+
+// decodeITerm decodes an inline image written by ITermEncoder into its
+// arguments and file data.
+func decodeITerm(t *testing.T, s string) (map[string]string, []byte) {
+	t.Helper()
+	m := iTermFileRE.FindStringSubmatch(s)
+	if m == nil {
+		t.Fatalf("invalid escape code: %q", s[:min(len(s), 40)])
+	}
+	data, err := base64.StdEncoding.DecodeString(m[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := make(map[string]string)
+	for _, kv := range strings.Split(m[1], ";") {
+		k, v, _ := strings.Cut(kv, "=")
+		args[k] = v
+	}
+	if args["size"] != strconv.Itoa(len(data)) {
+		t.Errorf("got size=%s, expected %d", args["size"], len(data))
+	}
+	if args["inline"] != "1" {
+		t.Errorf("got inline=%s, expected 1", args["inline"])
+	}
+	return args, data
+}
+
+func TestITermEncode(t *testing.T) {
+	img := newTestImage(5, 3)
+	var enc kittyimg.ITermEncoder
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	_, data := decodeITerm(t, buf.String())
+	got, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != img.Bounds() {
+		t.Fatalf("got bounds %v, expected %v", got.Bounds(), img.Bounds())
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			if c := color.NRGBAModel.Convert(got.At(x, y)); c != img.At(x, y) {
+				t.Fatalf("pixel (%d,%d): got %v, expected %v", x, y, c, img.At(x, y))
+			}
+		}
+	}
+}
+
+func TestITermEncodeArgs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		enc      kittyimg.ITermEncoder
+		expected map[string]string
+	}{
+		{"auto", kittyimg.ITermEncoder{}, map[string]string{}},
+		{"cells", kittyimg.ITermEncoder{Width: kittyimg.ITermCells(10)}, map[string]string{"width": "10"}},
+		{"pixels", kittyimg.ITermEncoder{Height: kittyimg.ITermPixels(64)}, map[string]string{"height": "64px"}},
+		{"percent", kittyimg.ITermEncoder{
+			Width:   kittyimg.ITermPercent(50),
+			Height:  kittyimg.ITermPercent(25),
+			Stretch: true,
+		}, map[string]string{"width": "50%", "height": "25%", "preserveAspectRatio": "0"}},
+		{"name", kittyimg.ITermEncoder{Name: "gopher.png"}, map[string]string{"name": base64.StdEncoding.EncodeToString([]byte("gopher.png"))}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.enc.Encode(&buf, newTestImage(2, 2)); err != nil {
+				t.Fatal(err)
+			}
+			args, _ := decodeITerm(t, buf.String())
+			for _, k := range []string{"name", "width", "height", "preserveAspectRatio"} {
+				if args[k] != tc.expected[k] {
+					t.Errorf("got %s=%q, expected %q", k, args[k], tc.expected[k])
+				}
+			}
+		})
+	}
+}
+
+func TestITermTranscode(t *testing.T) {
+	for _, file := range []string{"testdata/go-logo-blue.png", "testdata/spinner.gif"} {
+		t.Run(file, func(t *testing.T) {
+			raw, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var enc kittyimg.ITermEncoder
+			var buf bytes.Buffer
+			if err := enc.Transcode(&buf, bytes.NewReader(raw)); err != nil {
+				t.Fatal(err)
+			}
+			// The file is sent unchanged
+			if _, data := decodeITerm(t, buf.String()); !bytes.Equal(data, raw) {
+				t.Error("file data differs")
+			}
+		})
+	}
+}
+
+func TestITermMultipart(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 100, 100))
+	// Noise, to defeat compression
+	seed := uint32(1)
+	for i := range img.Pix {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+	}
+	enc := kittyimg.ITermEncoder{Multipart: true}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+
+	header, s, ok := strings.Cut(s, "\a")
+	if !ok || !strings.HasPrefix(header, "\033]1337;MultipartFile=") || !strings.HasSuffix(header, ";inline=1") {
+		t.Fatalf("invalid header: %q", header)
+	}
+	s, ok = strings.CutSuffix(s, "\033]1337;FileEnd\a")
+	if !ok {
+		t.Fatal("missing FileEnd")
+	}
+	var payload strings.Builder
+	parts := 0
+	for s != "" {
+		var part string
+		part, s, _ = strings.Cut(s, "\a")
+		chunk, ok := strings.CutPrefix(part, "\033]1337;FilePart=")
+		if !ok {
+			t.Fatalf("invalid part: %q", part[:min(len(part), 40)])
+		}
+		payload.WriteString(chunk)
+		parts++
+	}
+	if parts < 2 {
+		t.Errorf("got %d parts, expected more", parts)
+	}
+	data, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(header, "size="+strconv.Itoa(len(data))+";") {
+		t.Errorf("size mismatch in header %q: %d", header, len(data))
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,204 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Medium is the [transmission medium] of image data.
+//
+// Media other than MediumDirect work only if the terminal runs on the same
+// machine as the program.
+//
+// [transmission medium]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#the-transmission-medium
+type Medium int
+
+const (
+	// MediumDirect sends data in the escape codes (t=d).
+	MediumDirect Medium = iota
+	// MediumFile makes the terminal read data from a regular file (t=f).
+	// Data is appended to [Encoder.File] or, if File is nil, written to a
+	// temporary file like with MediumTempFile.
+	// [Encoder.Transcode] refers directly to the source file for PNG data
+	// read from an [*os.File].
+	MediumFile
+	// MediumTempFile makes the terminal read data from a temporary file that
+	// the terminal deletes once read (t=t).
+	MediumTempFile
+	// MediumSharedMemory makes the terminal read data from a POSIX shared
+	// memory object that the terminal unlinks once read (t=s).
+	// It is supported only on Linux, where objects live in /dev/shm.
+	MediumSharedMemory
+	// MediumAuto probes the terminal [Encoder.TTY] once with a query (a=q)
+	// of data in a temporary file. MediumFile is used if the terminal could
+	// read the file, MediumDirect otherwise (or if TTY is nil).
+	MediumAuto
+)
+
+// medium returns the transmission medium, probing the terminal once if the
+// medium is MediumAuto.
+func (enc *Encoder) medium() Medium {
+	if enc.Medium != MediumAuto {
+		return enc.Medium
+	}
+	if !enc.probed {
+		enc.autoMedium = probeMedium(enc.TTY)
+		enc.probed = true
+	}
+	return enc.autoMedium
+}
+
+// probeMedium queries the terminal tty with a 1x1 RGB image in a temporary
+// file (t=t). It returns MediumFile if the terminal could read the file,
+// MediumDirect otherwise.
+func probeMedium(tty *os.File) Medium {
+	if tty == nil {
+		return MediumDirect
+	}
+	f, err := os.CreateTemp("", tempFilePattern)
+	if err != nil {
+		return MediumDirect
+	}
+	// The terminal deletes the file only if it could read it
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte{0, 0, 0})
+	if errClose := f.Close(); err != nil || errClose != nil {
+		return MediumDirect
+	}
+
+	// No q=1 as we want the OK response
+	cmd := apcStart + "a=q,i=31,s=1,v=1,f=24,t=t;" + base64.StdEncoding.EncodeToString([]byte(f.Name())) + apcEnd
+	resp, err := query(context.Background(), tty, []byte(cmd))
+	if err != nil || resp == nil || resp.Err() != nil {
+		return MediumDirect
+	}
+	return MediumFile
+}
+
+// tempFilePattern is the pattern of temporary files names.
+// The terminal accepts to delete only files whose path contains
+// "tty-graphics-protocol".
+const tempFilePattern = "tty-graphics-protocol-*"
+
+// appendFileMedium appends the keys for data read from a regular file (t=f)
+// at offset (O=, omitted if 0) with the given size (S=, omitted if 0).
+func appendFileMedium(cmd []byte, offset, size int64) []byte {
+	cmd = appendKeyChar(cmd, 't', 'f')
+	if offset != 0 {
+		cmd = appendKeyInt(cmd, 'O', int(offset))
+	}
+	if size != 0 {
+		cmd = appendKeyInt(cmd, 'S', int(size))
+	}
+	return cmd
+}
+
+// sendFile writes data with write to a file (or a shared memory object) and
+// sends the command cmd with the path (or name) of the file as payload.
+func (enc *Encoder) sendFile(w io.Writer, cmd []byte, write func(io.Writer) error) error {
+	medium := enc.medium()
+	if medium == MediumFile && enc.File != nil {
+		offset, err := enc.File.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if err = write(enc.File); err != nil {
+			return err
+		}
+		end, err := enc.File.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		path, err := filepath.Abs(enc.File.Name())
+		if err != nil {
+			return err
+		}
+		return enc.sendPath(w, appendFileMedium(cmd, offset, end-offset), path)
+	}
+
+	var (
+		f    *os.File
+		name string
+		err  error
+	)
+	if medium == MediumSharedMemory {
+		f, name, err = createSharedMemory()
+		cmd = appendKeyChar(cmd, 't', 's')
+	} else {
+		f, err = os.CreateTemp("", tempFilePattern)
+		if f != nil {
+			name = f.Name()
+		}
+		cmd = appendKeyChar(cmd, 't', 't')
+	}
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = enc.sendPath(w, cmd, name)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// sendPath writes the command cmd with path as payload.
+func (enc *Encoder) sendPath(w io.Writer, cmd []byte, path string) error {
+	enc.cmd = cmd
+	if _, err := w.Write(cmd); err != nil {
+		return err
+	}
+
+	var pw *payloadWriter = &enc.pw.pw
+	pw.Reset(w)
+
+	if _, err := io.WriteString(pw, path); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// sourceFile returns the absolute path of r and its current offset if the
+// medium is MediumFile and r is a regular file.
+func (enc *Encoder) sourceFile(r io.Reader) (path string, offset int64) {
+	f, ok := r.(*os.File)
+	// The name of os.Stdin is not a path to the file
+	if !ok || f == os.Stdin || enc.medium() != MediumFile {
+		return "", 0
+	}
+	if fi, err := f.Stat(); err != nil || !fi.Mode().IsRegular() {
+		return "", 0
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0
+	}
+	if path, err = filepath.Abs(f.Name()); err != nil {
+		return "", 0
+	}
+	return path, offset
+}
@@ -0,0 +1,86 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestEncodeAutoMedium(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	for _, tc := range []struct {
+		name     string
+		reply    string
+		expected string
+	}{
+		{"local", "\033_Gi=31;OK\033\\\033[?62;c", "t"},
+		{"remote", "\033_Gi=31;EBADF:file not found\033\\\033[?62;c", "d"},
+		{"no-graphics", "\033[?1;2c", "d"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			master, tty := openPTY(t)
+			received := fakeTerminal(t, master, tc.reply)
+
+			enc := kittyimg.Encoder{Medium: kittyimg.MediumAuto, TTY: tty}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, newTestImage(2, 2)); err != nil {
+				t.Fatal(err)
+			}
+			q := <-received
+			t.Logf("%q", q)
+			if !bytes.Contains(q, []byte("a=q,")) || !bytes.Contains(q, []byte(",t=t;")) {
+				t.Error("probe not sent")
+			}
+
+			// The result is cached: the terminal is not probed again
+			master.Close()
+			if err := enc.Encode(&buf, newTestImage(2, 2)); err != nil {
+				t.Fatal(err)
+			}
+
+			n := 0
+			for bl := range extractBlocks(buf.Bytes()) {
+				if bl.Params['t'] != tc.expected {
+					t.Errorf("got t=%s, expected t=%s", bl.Params['t'], tc.expected)
+				}
+				n++
+			}
+			if n != 2 {
+				t.Errorf("got %d blocks", n)
+			}
+		})
+	}
+}
+
+func TestEncodeAutoMediumNoTTY(t *testing.T) {
+	enc := kittyimg.Encoder{Medium: kittyimg.MediumAuto}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, newTestImage(2, 2)); err != nil {
+		t.Fatal(err)
+	}
+	for bl := range extractBlocks(buf.Bytes()) {
+		if bl.Params['t'] != "d" {
+			t.Errorf("got t=%s", bl.Params['t'])
+		}
+	}
+}
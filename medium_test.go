@@ -0,0 +1,160 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// rgb returns the RGBA pixels pix without the alpha channel.
+func rgb(pix []byte) []byte {
+	var b []byte
+	for i := 0; i < len(pix); i += 4 {
+		b = append(b, pix[i:i+3]...)
+	}
+	return b
+}
+
+func TestEncodeTempFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	img := newTestImage(4, 3)
+	enc := kittyimg.Encoder{Medium: kittyimg.MediumTempFile}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for bl := range extractBlocks(buf.Bytes()) {
+		if got := bl.Params.String(); got != "a=T,f=24,q=1,s=4,t=t,v=3" {
+			t.Errorf("got %q", got)
+		}
+		path := string(bl.Payload)
+		if filepath.Dir(path) != dir || !strings.Contains(path, "tty-graphics-protocol") {
+			t.Errorf("unexpected path %q", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, rgb(img.Pix)) {
+			t.Errorf("got %x", data)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d blocks", n)
+	}
+}
+
+func TestEncodeFile(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	enc := kittyimg.Encoder{Medium: kittyimg.MediumFile, File: f}
+	images := []*image.NRGBA{newTestImage(4, 3), newTestImage(2, 5)}
+	var buf bytes.Buffer
+	for _, img := range images {
+		if err := enc.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	i := 0
+	offset := 0
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		img := images[i]
+		size := len(img.Pix) / 4 * 3
+		if bl.Params['t'] != "f" || bl.Params['S'] != strconv.Itoa(size) {
+			t.Errorf("block %d: unexpected params %s", i, bl.Params)
+		}
+		if o := bl.Params['O']; (offset == 0 && o != "") || (offset != 0 && o != strconv.Itoa(offset)) {
+			t.Errorf("block %d: got O=%s, expected %d", i, o, offset)
+		}
+		if string(bl.Payload) != f.Name() {
+			t.Errorf("block %d: got path %q", i, bl.Payload)
+		}
+		data := make([]byte, size)
+		if _, err := f.ReadAt(data, int64(offset)); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, rgb(img.Pix)) {
+			t.Errorf("block %d: got %x", i, data)
+		}
+		offset += size
+		i++
+	}
+	if i != len(images) {
+		t.Errorf("got %d blocks", i)
+	}
+}
+
+func TestTranscodeSourceFile(t *testing.T) {
+	png, err := os.ReadFile("testdata/go-favicon-1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The PNG file is embedded in another file
+	path := filepath.Join(t.TempDir(), "archive")
+	if err = os.WriteFile(path, append([]byte("header"), png...), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	enc := kittyimg.Encoder{Medium: kittyimg.MediumFile}
+	var buf bytes.Buffer
+	if err = enc.Transcode(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		if bl.Params['f'] != "100" || bl.Params['t'] != "f" || bl.Params['O'] != "6" {
+			t.Errorf("unexpected params %s", bl.Params)
+		}
+		if string(bl.Payload) != path {
+			t.Errorf("got path %q, expected %q", bl.Payload, path)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d blocks", n)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// screenMaxString is the maximum length of strings that GNU screen handles.
+const screenMaxString = 768
+
+// NewPassthroughWriter returns a writer that wraps the graphics protocol
+// escape codes written to w in the passthrough envelope of the terminal
+// multiplexer detected from the environment: tmux (TMUX) or GNU screen (STY).
+// Other output is not changed. If no multiplexer is detected, w is returned.
+//
+// Each command (\033_G...\033\\) is wrapped separately, so the chunking of
+// payloads is preserved. Other escape codes are not wrapped. tmux forwards
+// the envelope to the terminal only if its allow-passthrough option is on.
+//
+// The start of an escape code at the end of a write (ESC, or ESC _) is held
+// back until the next write tells whether it starts a command. If the output
+// may end there, call the Flush method of the writer, which is available as
+// interface{ Flush() error }.
+func NewPassthroughWriter(w io.Writer) io.Writer {
+	switch {
+	case os.Getenv("TMUX") != "":
+		return &passthroughWriter{w: w}
+	case os.Getenv("STY") != "":
+		return &passthroughWriter{w: w, screen: true}
+	}
+	return w
+}
+
+type passthroughWriter struct {
+	w       io.Writer
+	screen  bool
+	pending []byte // the start of a possible command (ESC, ESC _)
+	inSeq   bool   // a command is being written
+	seq     []byte // the command being written
+	buf     []byte
+}
+
+func (pw *passthroughWriter) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		if pw.inSeq {
+			// Look for the string terminator (ESC \)
+			i := bytes.IndexByte(b, '\\')
+			if i < 0 {
+				pw.seq = append(pw.seq, b...)
+				break
+			}
+			pw.seq = append(pw.seq, b[:i+1]...)
+			b = b[i+1:]
+			if len(pw.seq) >= 4 && pw.seq[len(pw.seq)-2] == '\033' {
+				if err := pw.wrap(); err != nil {
+					return n - len(b), err
+				}
+			}
+			continue
+		}
+
+		if len(pw.pending) > 0 {
+			if b[0] == apcStart[len(pw.pending)] {
+				pw.pending = append(pw.pending, b[0])
+				b = b[1:]
+				if len(pw.pending) == len(apcStart) {
+					pw.inSeq = true
+					pw.seq = append(pw.seq[:0], pw.pending...)
+					pw.pending = pw.pending[:0]
+				}
+				continue
+			}
+			// Not a command
+			if err := pw.Flush(); err != nil {
+				return n - len(b), err
+			}
+		}
+
+		i := bytes.IndexByte(b, '\033')
+		if i < 0 {
+			i = len(b)
+		}
+		if i > 0 {
+			if _, err := pw.w.Write(b[:i]); err != nil {
+				return n - len(b), err
+			}
+		}
+		if i < len(b) {
+			pw.pending = append(pw.pending[:0], '\033')
+			i++
+		}
+		b = b[i:]
+	}
+	return n, nil
+}
+
+// Flush writes the bytes held back at the end of the last write: the start
+// of an escape code, or an incomplete command, which are written unchanged.
+func (pw *passthroughWriter) Flush() error {
+	b := pw.pending
+	if pw.inSeq {
+		b = pw.seq
+		pw.inSeq = false
+	}
+	pw.pending = pw.pending[:0]
+	if len(b) == 0 {
+		return nil
+	}
+	_, err := pw.w.Write(b)
+	return err
+}
+
+// wrap writes the command in pw.seq wrapped in the passthrough envelope.
+func (pw *passthroughWriter) wrap() error {
+	buf := pw.buf[:0]
+	if pw.screen {
+		// Strings are limited in size, and ESC \ would terminate the string
+		// early, so the ESC of the command string terminator must end a
+		// string. The ESC that starts the command is sent with it, as ESC
+		// followed by another character is kept in the string (like
+		// iTerm2's imgcat does for screen with "\033P\033]").
+		for seq, start := pw.seq, 1; len(seq) > 0; start = 0 {
+			n := len(seq)
+			if i := bytes.IndexByte(seq[start:], '\033'); i >= 0 {
+				n = start + i + 1
+			}
+			n = min(n, screenMaxString)
+			buf = append(buf, "\033P"...)
+			buf = append(buf, seq[:n]...)
+			buf = append(buf, "\033\\"...)
+			seq = seq[n:]
+		}
+	} else {
+		buf = append(buf, "\033Ptmux;"...)
+		for _, c := range pw.seq {
+			if c == '\033' {
+				buf = append(buf, '\033')
+			}
+			buf = append(buf, c)
+		}
+		buf = append(buf, "\033\\"...)
+	}
+	pw.buf = buf
+	pw.inSeq = false
+	_, err := pw.w.Write(buf)
+	return err
+}
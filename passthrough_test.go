@@ -0,0 +1,154 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// encodeNoise returns the output of the encoding of an image large enough to
+// be sent in multiple chunks, followed by some text.
+func encodeNoise(t *testing.T) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	rnd := rand.New(rand.NewSource(1))
+	rnd.Read(img.Pix)
+	var buf bytes.Buffer
+	var enc kittyimg.Encoder
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	buf.WriteString("\n\033[1mbold\033[0m\n")
+	return buf.Bytes()
+}
+
+// writeSplit writes b to w in small pieces.
+func writeSplit(t *testing.T, w interface{ Write([]byte) (int, error) }, b []byte) {
+	for i := 0; len(b) > 0; i++ {
+		n := min(len(b), 1+i%7)
+		if _, err := w.Write(b[:n]); err != nil {
+			t.Fatal(err)
+		}
+		b = b[n:]
+	}
+}
+
+func TestPassthroughTmux(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	t.Setenv("STY", "")
+
+	raw := encodeNoise(t)
+	var buf bytes.Buffer
+	writeSplit(t, kittyimg.NewPassthroughWriter(&buf), raw)
+	out := buf.String()
+
+	envelopeRE := regexp.MustCompile("\033Ptmux;((?:[^\033]|\033\033)*)\033\\\\")
+	envelopes := envelopeRE.FindAllStringSubmatch(out, -1)
+	if len(envelopes) < 2 {
+		t.Fatalf("got %d envelopes", len(envelopes))
+	}
+	for _, m := range envelopes {
+		cmd := strings.ReplaceAll(m[1], "\033\033", "\033")
+		// One command per envelope
+		if !strings.HasPrefix(cmd, "\033_G") || strings.Index(cmd, "\033\\") != len(cmd)-2 {
+			t.Fatalf("unexpected envelope content %q", cmd)
+		}
+	}
+	unwrapped := envelopeRE.ReplaceAllStringFunc(out, func(s string) string {
+		return strings.ReplaceAll(envelopeRE.FindStringSubmatch(s)[1], "\033\033", "\033")
+	})
+	if unwrapped != string(raw) {
+		t.Errorf("got %q, expected %q", unwrapped, raw)
+	}
+}
+
+func TestPassthroughScreen(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "1234.pts-0.host")
+
+	raw := encodeNoise(t)
+	var buf bytes.Buffer
+	writeSplit(t, kittyimg.NewPassthroughWriter(&buf), raw)
+	out := buf.String()
+
+	envelopeRE := regexp.MustCompile("\033P(\033?[^\033]*\033?)\033\\\\")
+	for _, m := range envelopeRE.FindAllStringSubmatch(out, -1) {
+		if len(m[1]) > 768 {
+			t.Fatalf("envelope too long: %d bytes", len(m[1]))
+		}
+		// The ESC that starts a command is sent with it
+		if m[1] == "\033" {
+			t.Fatal("lone ESC envelope")
+		}
+	}
+	unwrapped := envelopeRE.ReplaceAllString(out, "$1")
+	if unwrapped != string(raw) {
+		t.Errorf("got %q, expected %q", unwrapped, raw)
+	}
+}
+
+func TestPassthroughSplitEscape(t *testing.T) {
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	t.Setenv("STY", "")
+
+	for _, tc := range []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{"trailing-ESC", []string{"text\033"}, "text\033"},
+		{"trailing-APC", []string{"text\033_"}, "text\033_"},
+		{"incomplete", []string{"\033_Ga=T;AAAA"}, "\033_Ga=T;AAAA"},
+		{"SGR", []string{"a\033", "[1mb\033", "\033", "[0m"}, "a\033[1mb\033\033[0m"},
+		{"command", []string{"a\033", "_", "Ga=d\033", "\\b"}, "a\033Ptmux;\033\033_Ga=d\033\033\\\033\\b"},
+		{"other-APC", []string{"\033", "_X\033\\"}, "\033_X\033\\"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := kittyimg.NewPassthroughWriter(&buf)
+			for _, s := range tc.writes {
+				if _, err := w.Write([]byte(s)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.(interface{ Flush() error }).Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("got %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestPassthroughNone(t *testing.T) {
+	t.Setenv("TMUX", "")
+	t.Setenv("STY", "")
+
+	var buf bytes.Buffer
+	if w := kittyimg.NewPassthroughWriter(&buf); w != &buf {
+		t.Errorf("got %T", w)
+	}
+}
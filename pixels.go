@@ -0,0 +1,240 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"image/color"
+	"io"
+)
+
+// Format is the pixel format (f=) used by [Encoder] to transmit images.
+//
+// See [transferring pixel data].
+//
+// [transferring pixel data]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#transferring-pixel-data
+type Format int
+
+const (
+	// FormatAuto selects FormatRGB for opaque images and FormatRGBA otherwise.
+	FormatAuto Format = 0
+	// FormatRGB sends 3 bytes per pixel (f=24). The alpha channel is dropped.
+	FormatRGB Format = 24
+	// FormatRGBA sends 4 bytes per pixel (f=32).
+	FormatRGBA Format = 32
+)
+
+// format returns the pixel format for img.
+func (enc *Encoder) format(img image.Image) Format {
+	if enc.Format != FormatAuto {
+		return enc.Format
+	}
+	if isOpaque(img) {
+		return FormatRGB
+	}
+	return FormatRGBA
+}
+
+// isOpaque reports whether all pixels of img are fully opaque.
+// The Opaque method implemented by most concrete image types of package [image]
+// is used if available.
+func isOpaque(img image.Image) bool {
+	if img, ok := img.(interface{ Opaque() bool }); ok {
+		return img.Opaque()
+	}
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a != 0xffff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// writePixels writes the pixels of img to dst in the given format.
+func (enc *Encoder) writePixels(dst io.Writer, img image.Image, format Format) error {
+	bounds := img.Bounds()
+
+	bufCap := min(bounds.Dx()*bounds.Dy()*4, 16384) // Multiple of 4 (RGBA)
+	buf := enc.buf
+	if cap(enc.buf) < bufCap {
+		buf = make([]byte, 0, bufCap)
+		enc.buf = buf
+	} else {
+		buf = buf[:0]
+	}
+
+	appendPixels := rgbaAppender(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; {
+			// Pixels are converted to RGBA first, so we need room for 4 bytes
+			if cap(buf)-len(buf) < 4 {
+				if _, err := dst.Write(buf); err != nil {
+					return err
+				}
+				buf = buf[:0]
+			}
+			n := min(bounds.Max.X-x, (cap(buf)-len(buf))/4)
+			if format == FormatRGB {
+				buf = dropAlpha(appendPixels(buf, x, y, n), len(buf))
+			} else {
+				buf = appendPixels(buf, x, y, n)
+			}
+			x += n
+		}
+	}
+
+	_, err := dst.Write(buf)
+	return err
+}
+
+// dropAlpha converts in place the RGBA pixels of buf[start:] to RGB.
+func dropAlpha(buf []byte, start int) []byte {
+	j := start
+	for i := start; i < len(buf); i += 4 {
+		buf[j], buf[j+1], buf[j+2] = buf[i], buf[i+1], buf[i+2]
+		j += 3
+	}
+	return buf[:j]
+}
+
+// pixelAppender appends to buf the n pixels of row y starting at column x.
+type pixelAppender func(buf []byte, x, y, n int) []byte
+
+// rgbaAppender returns a pixelAppender that converts pixels of img to RGBA
+// with straight (non-premultiplied) alpha, as expected by f=32.
+//
+// Common concrete image types are read directly from their Pix slices. Other
+// images go through the [image.Image] interface, which allocates a
+// [color.Color] for each pixel.
+func rgbaAppender(img image.Image) pixelAppender {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		return func(buf []byte, x, y, n int) []byte {
+			i := img.PixOffset(x, y)
+			return append(buf, img.Pix[i:i+4*n]...)
+		}
+	case *image.RGBA:
+		return func(buf []byte, x, y, n int) []byte {
+			i := img.PixOffset(x, y)
+			pix := img.Pix[i : i+4*n]
+			for j := 0; j < len(pix); j += 4 {
+				buf = appendUnpremultiplied(buf, pix[j], pix[j+1], pix[j+2], pix[j+3])
+			}
+			return buf
+		}
+	case *image.RGBA64:
+		return func(buf []byte, x, y, n int) []byte {
+			i := img.PixOffset(x, y)
+			pix := img.Pix[i : i+8*n]
+			for j := 0; j < len(pix); j += 8 {
+				buf = appendUnpremultiplied64(buf,
+					uint32(pix[j])<<8|uint32(pix[j+1]),
+					uint32(pix[j+2])<<8|uint32(pix[j+3]),
+					uint32(pix[j+4])<<8|uint32(pix[j+5]),
+					uint32(pix[j+6])<<8|uint32(pix[j+7]),
+				)
+			}
+			return buf
+		}
+	case *image.Paletted:
+		if len(img.Palette) == 0 {
+			break
+		}
+		// Pixel indexes are uint8: entries beyond 256 are never used
+		var palette [256][4]byte
+		for i, c := range img.Palette[:min(len(img.Palette), 256)] {
+			c := color.NRGBAModel.Convert(c).(color.NRGBA)
+			palette[i] = [4]byte{c.R, c.G, c.B, c.A}
+		}
+		return func(buf []byte, x, y, n int) []byte {
+			i := img.PixOffset(x, y)
+			for _, p := range img.Pix[i : i+n] {
+				buf = append(buf, palette[p][:]...)
+			}
+			return buf
+		}
+	case *image.Gray:
+		return func(buf []byte, x, y, n int) []byte {
+			i := img.PixOffset(x, y)
+			for _, g := range img.Pix[i : i+n] {
+				buf = append(buf, g, g, g, 0xff)
+			}
+			return buf
+		}
+	case *image.YCbCr:
+		return func(buf []byte, x, y, n int) []byte {
+			for ; n > 0; n-- {
+				yi, ci := img.YOffset(x, y), img.COffset(x, y)
+				// Same conversion as the RGBA method of the color returned by At
+				r, g, b, _ := color.YCbCr{Y: img.Y[yi], Cb: img.Cb[ci], Cr: img.Cr[ci]}.RGBA()
+				buf = append(buf, uint8(r>>8), uint8(g>>8), uint8(b>>8), 0xff)
+				x++
+			}
+			return buf
+		}
+	}
+	return func(buf []byte, x, y, n int) []byte {
+		for ; n > 0; n-- {
+			// f=32 expects straight (non-premultiplied) alpha, but a color's
+			// RGBA method returns alpha-premultiplied values.
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			buf = append(buf, c.R, c.G, c.B, c.A)
+			x++
+		}
+		return buf
+	}
+}
+
+// appendUnpremultiplied appends an alpha-premultiplied 8 bits color as straight RGBA.
+// The result is the same as [color.NRGBAModel].
+func appendUnpremultiplied(buf []byte, r, g, b, a uint8) []byte {
+	switch a {
+	case 0xff:
+		return append(buf, r, g, b, a)
+	case 0:
+		return append(buf, 0, 0, 0, 0)
+	}
+	// Equivalent to the computation done by color.NRGBAModel on 16 bits values
+	// as 0x101 cancels out.
+	a32 := uint32(a)
+	return append(buf,
+		uint8((uint32(r)*0xffff/a32)>>8),
+		uint8((uint32(g)*0xffff/a32)>>8),
+		uint8((uint32(b)*0xffff/a32)>>8),
+		a,
+	)
+}
+
+// appendUnpremultiplied64 appends an alpha-premultiplied 16 bits color as straight RGBA.
+// The result is the same as [color.NRGBAModel].
+func appendUnpremultiplied64(buf []byte, r, g, b, a uint32) []byte {
+	switch a {
+	case 0xffff:
+		return append(buf, uint8(r>>8), uint8(g>>8), uint8(b>>8), 0xff)
+	case 0:
+		return append(buf, 0, 0, 0, 0)
+	}
+	return append(buf,
+		uint8((r*0xffff/a)>>8),
+		uint8((g*0xffff/a)>>8),
+		uint8((b*0xffff/a)>>8),
+		uint8(a>>8),
+	)
+}
@@ -0,0 +1,163 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// concreteImages returns src converted to each of the image types that have a
+// fast path in Encoder.Encode. Images have a non-zero origin.
+func concreteImages(src image.Image) map[string]image.Image {
+	r := src.Bounds().Add(image.Pt(3, 5))
+	convert := func(dst draw.Image) image.Image {
+		draw.Draw(dst, r, src, src.Bounds().Min, draw.Src)
+		return dst
+	}
+
+	ycbcr := image.NewYCbCr(r, image.YCbCrSubsampleRatio420)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x-3, y-5)).(color.NRGBA)
+			yy, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+			ycbcr.Y[ycbcr.YOffset(x, y)] = yy
+			ycbcr.Cb[ycbcr.COffset(x, y)] = cb
+			ycbcr.Cr[ycbcr.COffset(x, y)] = cr
+		}
+	}
+
+	return map[string]image.Image{
+		"NRGBA":    convert(image.NewNRGBA(r)),
+		"RGBA":     convert(image.NewRGBA(r)),
+		"RGBA64":   convert(image.NewRGBA64(r)),
+		"Paletted": convert(image.NewPaletted(r, append(palette.Plan9[:255:255], color.Transparent))),
+		// A palette may hold more entries than indexes can reach
+		"Paletted-300": convert(image.NewPaletted(r, append(append(palette.Plan9[:255:255], color.Transparent), slices.Repeat(color.Palette{color.Black}, 44)...))),
+		"Gray":         convert(image.NewGray(r)),
+		"YCbCr":        ycbcr,
+	}
+}
+
+func TestEncodeFastPaths(t *testing.T) {
+	for name, img := range concreteImages(newAlphaImage()) {
+		t.Run(name, func(t *testing.T) {
+			var enc kittyimg.Encoder
+			var fast, generic bytes.Buffer
+			if err := enc.Encode(&fast, img); err != nil {
+				t.Fatal(err)
+			}
+			if err := enc.Encode(&generic, imageOnly{img}); err != nil {
+				t.Fatal(err)
+			}
+			_, fastPix := decodeImage(t, fast.Bytes())
+			_, genericPix := decodeImage(t, generic.Bytes())
+			if !bytes.Equal(fastPix, genericPix) {
+				for i := range fastPix {
+					if fastPix[i] != genericPix[i] {
+						t.Fatalf("pixel %d, channel %d: got %#02x, expected %#02x", i/4, i%4, fastPix[i], genericPix[i])
+					}
+				}
+				t.Fatalf("got %d bytes, expected %d", len(fastPix), len(genericPix))
+			}
+		})
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	// 4K screenshot
+	src := image.NewNRGBA(image.Rect(0, 0, 3840, 2160))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 7 / 5)
+	}
+	for name, img := range concreteImages(src) {
+		var enc kittyimg.Encoder
+		b.Run(name, func(b *testing.B) {
+			for b.Loop() {
+				if err := enc.Encode(io.Discard, img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/generic", func(b *testing.B) {
+			img := imageOnly{img}
+			for b.Loop() {
+				if err := enc.Encode(io.Discard, img); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeFormat(t *testing.T) {
+	opaque := newTestImage(5, 3)
+	for _, tc := range []struct {
+		name     string
+		format   kittyimg.Format
+		img      image.Image
+		expected string
+	}{
+		{"opaque", kittyimg.FormatAuto, opaque, "24"},
+		{"opaque-scan", kittyimg.FormatAuto, imageOnly{opaque}, "24"},
+		{"opaque-large", kittyimg.FormatAuto, newTestImage(200, 100), "24"},
+		{"opaque-RGBA", kittyimg.FormatRGBA, opaque, "32"},
+		{"alpha", kittyimg.FormatAuto, newAlphaImage(), "32"},
+		{"alpha-scan", kittyimg.FormatAuto, imageOnly{newAlphaImage()}, "32"},
+		{"alpha-RGB", kittyimg.FormatRGB, newAlphaImage(), "24"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			enc := kittyimg.Encoder{Format: tc.format}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, tc.img); err != nil {
+				t.Fatal(err)
+			}
+			params, pix := decodeImage(t, buf.Bytes())
+			if params['f'] != tc.expected {
+				t.Fatalf("got f=%s, expected f=%s", params['f'], tc.expected)
+			}
+
+			var ref []byte
+			switch img := tc.img.(type) {
+			case imageOnly:
+				ref = img.Image.(*image.NRGBA).Pix
+			case *image.NRGBA:
+				ref = img.Pix
+			}
+			if tc.expected == "24" {
+				var rgb []byte
+				for i := 0; i < len(ref); i += 4 {
+					rgb = append(rgb, ref[i:i+3]...)
+				}
+				ref = rgb
+			}
+			if !bytes.Equal(pix, ref) {
+				t.Errorf("got %x, expected %x", pix, ref)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// PlaceholderRune is the character of the cells where a virtual placement
+// (see [Placement.Virtual]) is displayed.
+//
+// See [Unicode placeholders].
+//
+// [Unicode placeholders]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#unicode-placeholders
+const PlaceholderRune = '\U0010EEEE'
+
+// placeholderDiacritics are the combining characters that encode the row, the
+// column and the most significant byte of the image ID of a placeholder cell.
+// This is kitty's rowcolumn-diacritics.txt: the combining characters of class
+// 230 of Unicode 6.0 that have no decomposition and are not part of one.
+var placeholderDiacritics = [...]rune{
+	0x0305, 0x030d, 0x030e, 0x0310, 0x0312, 0x033d, 0x033e, 0x033f,
+	0x0346, 0x034a, 0x034b, 0x034c, 0x0350, 0x0351, 0x0352, 0x0357,
+	0x035b, 0x0363, 0x0364, 0x0365, 0x0366, 0x0367, 0x0368, 0x0369,
+	0x036a, 0x036b, 0x036c, 0x036d, 0x036e, 0x036f, 0x0483, 0x0484,
+	0x0485, 0x0486, 0x0487, 0x0592, 0x0593, 0x0594, 0x0595, 0x0597,
+	0x0598, 0x0599, 0x059c, 0x059d, 0x059e, 0x059f, 0x05a0, 0x05a1,
+	0x05a8, 0x05a9, 0x05ab, 0x05ac, 0x05af, 0x05c4, 0x0610, 0x0611,
+	0x0612, 0x0613, 0x0614, 0x0615, 0x0616, 0x0617, 0x0657, 0x0658,
+	0x0659, 0x065a, 0x065b, 0x065d, 0x065e, 0x06d6, 0x06d7, 0x06d8,
+	0x06d9, 0x06da, 0x06db, 0x06dc, 0x06df, 0x06e0, 0x06e1, 0x06e2,
+	0x06e4, 0x06e7, 0x06e8, 0x06eb, 0x06ec, 0x0730, 0x0732, 0x0733,
+	0x0735, 0x0736, 0x073a, 0x073d, 0x073f, 0x0740, 0x0741, 0x0743,
+	0x0745, 0x0747, 0x0749, 0x074a, 0x07eb, 0x07ec, 0x07ed, 0x07ee,
+	0x07ef, 0x07f0, 0x07f1, 0x07f3, 0x0816, 0x0817, 0x0818, 0x0819,
+	0x081b, 0x081c, 0x081d, 0x081e, 0x081f, 0x0820, 0x0821, 0x0822,
+	0x0823, 0x0825, 0x0826, 0x0827, 0x0829, 0x082a, 0x082b, 0x082c,
+	0x082d, 0x0951, 0x0953, 0x0954, 0x0f82, 0x0f83, 0x0f86, 0x0f87,
+	0x135d, 0x135e, 0x135f, 0x17dd, 0x193a, 0x1a17, 0x1a75, 0x1a76,
+	0x1a77, 0x1a78, 0x1a79, 0x1a7a, 0x1a7b, 0x1a7c, 0x1b6b, 0x1b6d,
+	0x1b6e, 0x1b6f, 0x1b70, 0x1b71, 0x1b72, 0x1b73, 0x1cd0, 0x1cd1,
+	0x1cd2, 0x1cda, 0x1cdb, 0x1ce0, 0x1dc0, 0x1dc1, 0x1dc3, 0x1dc4,
+	0x1dc5, 0x1dc6, 0x1dc7, 0x1dc8, 0x1dc9, 0x1dcb, 0x1dcc, 0x1dd1,
+	0x1dd2, 0x1dd3, 0x1dd4, 0x1dd5, 0x1dd6, 0x1dd7, 0x1dd8, 0x1dd9,
+	0x1dda, 0x1ddb, 0x1ddc, 0x1ddd, 0x1dde, 0x1ddf, 0x1de0, 0x1de1,
+	0x1de2, 0x1de3, 0x1de4, 0x1de5, 0x1de6, 0x1dfe, 0x20d0, 0x20d1,
+	0x20d4, 0x20d5, 0x20d6, 0x20d7, 0x20db, 0x20dc, 0x20e1, 0x20e7,
+	0x20e9, 0x20f0, 0x2cef, 0x2cf0, 0x2cf1, 0x2de0, 0x2de1, 0x2de2,
+	0x2de3, 0x2de4, 0x2de5, 0x2de6, 0x2de7, 0x2de8, 0x2de9, 0x2dea,
+	0x2deb, 0x2dec, 0x2ded, 0x2dee, 0x2def, 0x2df0, 0x2df1, 0x2df2,
+	0x2df3, 0x2df4, 0x2df5, 0x2df6, 0x2df7, 0x2df8, 0x2df9, 0x2dfa,
+	0x2dfb, 0x2dfc, 0x2dfd, 0x2dfe, 0x2dff, 0xa66f, 0xa67c, 0xa67d,
+	0xa6f0, 0xa6f1, 0xa8e0, 0xa8e1, 0xa8e2, 0xa8e3, 0xa8e4, 0xa8e5,
+	0xa8e6, 0xa8e7, 0xa8e8, 0xa8e9, 0xa8ea, 0xa8eb, 0xa8ec, 0xa8ed,
+	0xa8ee, 0xa8ef, 0xa8f0, 0xa8f1, 0xaab0, 0xaab2, 0xaab3, 0xaab7,
+	0xaab8, 0xaabe, 0xaabf, 0xaac1, 0xfe20, 0xfe21, 0xfe22, 0xfe23,
+	0xfe24, 0xfe25, 0xfe26, 0x10a0f, 0x10a38, 0x1d185, 0x1d186, 0x1d187,
+	0x1d188, 0x1d189, 0x1d1aa, 0x1d1ab, 0x1d1ac, 0x1d1ad, 0x1d242, 0x1d243,
+	0x1d244,
+}
+
+// MaxPlaceholderSize is the maximum number of columns and rows of a virtual
+// placement, as the row and the column of each placeholder cell are encoded
+// with a combining character.
+const MaxPlaceholderSize = len(placeholderDiacritics)
+
+// Placeholder is the text that displays a virtual placement of an image: a
+// grid of Rows × Columns cells of [PlaceholderRune] printed with a foreground
+// color that encodes the image ID (and an underline color that encodes the
+// placement ID).
+//
+// As the image is ordinary text, it can be moved, scrolled and clipped by the
+// application (or tmux) like any other text.
+//
+// Only the first [MaxPlaceholderSize] columns and rows can be displayed.
+type Placeholder struct {
+	ImageID       uint32
+	PlacementID   uint32 // 0 if the placement has no ID
+	Columns, Rows int
+}
+
+// Placeholder returns the text that displays the virtual placement p of the
+// image. p.Columns and p.Rows must be set. If p is nil, the placeholder has
+// no cells.
+func (img *Image) Placeholder(p *Placement) *Placeholder {
+	if p == nil {
+		return &Placeholder{ImageID: img.ID}
+	}
+	return &Placeholder{
+		ImageID:     img.ID,
+		PlacementID: p.ID,
+		Columns:     p.Columns,
+		Rows:        p.Rows,
+	}
+}
+
+// Cell returns the text of the cell at row and column (0-based):
+// [PlaceholderRune] followed by combining characters that encode the row, the
+// column and the most significant byte of the image ID.
+//
+// The cell must be printed with the [Placeholder.Foreground] color, and with
+// the [Placeholder.Underline] color if PlacementID is not 0.
+//
+// Cell returns "" if row or column is not in the range 0 to
+// [MaxPlaceholderSize]-1.
+func (p *Placeholder) Cell(row, column int) string {
+	if row < 0 || row >= MaxPlaceholderSize || column < 0 || column >= MaxPlaceholderSize {
+		return ""
+	}
+	return string(p.appendCell(make([]byte, 0, 16), row, column, true))
+}
+
+// appendCell appends the cell at row and column. If full is false, only
+// [PlaceholderRune] is appended: the terminal infers the cell from the cell at
+// its left.
+func (p *Placeholder) appendCell(b []byte, row, column int, full bool) []byte {
+	b = append(b, string(PlaceholderRune)...)
+	if !full {
+		return b
+	}
+	b = append(b, string(placeholderDiacritics[row])...)
+	b = append(b, string(placeholderDiacritics[column])...)
+	if msb := p.ImageID >> 24; msb != 0 {
+		b = append(b, string(placeholderDiacritics[msb])...)
+	}
+	return b
+}
+
+// Foreground returns the 24-bit color that encodes the 3 least significant
+// bytes of the image ID.
+func (p *Placeholder) Foreground() color.RGBA {
+	return idColor(p.ImageID)
+}
+
+// Underline returns the 24-bit color that encodes the placement ID.
+func (p *Placeholder) Underline() color.RGBA {
+	return idColor(p.PlacementID)
+}
+
+func idColor(id uint32) color.RGBA {
+	return color.RGBA{R: uint8(id >> 16), G: uint8(id >> 8), B: uint8(id), A: 0xff}
+}
+
+// appendSGRColor appends the SGR sequence that sets the color c with the SGR
+// parameter param (38 for foreground, 58 for underline).
+func appendSGRColor(b []byte, param int, c color.RGBA) []byte {
+	b = append(b, "\033["...)
+	b = strconv.AppendInt(b, int64(param), 10)
+	b = append(b, ";2;"...)
+	b = strconv.AppendUint(b, uint64(c.R), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.G), 10)
+	b = append(b, ';')
+	b = strconv.AppendUint(b, uint64(c.B), 10)
+	return append(b, 'm')
+}
+
+// Lines returns the rows of cells, each with the SGR escape sequences that
+// set and reset the colors, ready to be printed. Only the first cell of each
+// row has combining characters: the terminal infers the others.
+//
+// Cells beyond [MaxPlaceholderSize] columns and rows are left out.
+func (p *Placeholder) Lines() []string {
+	columns := min(p.Columns, MaxPlaceholderSize)
+	lines := make([]string, max(min(p.Rows, MaxPlaceholderSize), 0))
+	var b []byte
+	for row := range lines {
+		b = appendSGRColor(b[:0], 38, p.Foreground())
+		if p.PlacementID != 0 {
+			b = appendSGRColor(b, 58, p.Underline())
+		}
+		for column := 0; column < columns; column++ {
+			b = p.appendCell(b, row, column, column == 0)
+		}
+		b = append(b, "\033[39m"...)
+		if p.PlacementID != 0 {
+			b = append(b, "\033[59m"...)
+		}
+		lines[row] = string(b)
+	}
+	return lines
+}
+
+// String returns the [Placeholder.Lines] joined with newlines.
+func (p *Placeholder) String() string {
+	return strings.Join(p.Lines(), "\n")
+}
@@ -0,0 +1,144 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestPlaceholderCell(t *testing.T) {
+	for _, tc := range []struct {
+		id          uint32
+		row, column int
+		expected    string
+	}{
+		{42, 0, 0, "\U0010EEEE\u0305\u0305"},
+		{42, 1, 3, "\U0010EEEE\u030d\u0310"},
+		{42, 296, 0, "\U0010EEEE\U0001D244\u0305"},
+		// Most significant byte of the ID
+		{0x01000002, 0, 1, "\U0010EEEE\u0305\u030d\u030d"},
+		// Out of range
+		{42, 297, 0, ""},
+		{42, 0, 297, ""},
+		{42, -1, 0, ""},
+	} {
+		p := kittyimg.Placeholder{ImageID: tc.id, Columns: 300, Rows: 300}
+		if got := p.Cell(tc.row, tc.column); got != tc.expected {
+			t.Errorf("%#x (%d, %d): got %+q, expected %+q", tc.id, tc.row, tc.column, got, tc.expected)
+		}
+	}
+}
+
+func TestPlaceholderColors(t *testing.T) {
+	p := kittyimg.Placeholder{ImageID: 0xff123456, PlacementID: 0x0a0b0c}
+	if got, expected := p.Foreground(), (color.RGBA{0x12, 0x34, 0x56, 0xff}); got != expected {
+		t.Errorf("foreground: got %v, expected %v", got, expected)
+	}
+	if got, expected := p.Underline(), (color.RGBA{0x0a, 0x0b, 0x0c, 0xff}); got != expected {
+		t.Errorf("underline: got %v, expected %v", got, expected)
+	}
+}
+
+func TestPlaceholderLines(t *testing.T) {
+	img := kittyimg.Image{ID: 42}
+	for _, tc := range []struct {
+		name      string
+		placement kittyimg.Placement
+		expected  []string
+	}{
+		{"image", kittyimg.Placement{Virtual: true, Columns: 3, Rows: 2}, []string{
+			"\033[38;2;0;0;42m\U0010EEEE\u0305\u0305\U0010EEEE\U0010EEEE\033[39m",
+			"\033[38;2;0;0;42m\U0010EEEE\u030d\u0305\U0010EEEE\U0010EEEE\033[39m",
+		}},
+		{"placement", kittyimg.Placement{ID: 258, Virtual: true, Columns: 1, Rows: 1}, []string{
+			"\033[38;2;0;0;42m\033[58;2;0;1;2m\U0010EEEE\u0305\u0305\033[39m\033[59m",
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := img.Placeholder(&tc.placement).Lines()
+			if len(lines) != len(tc.expected) {
+				t.Fatalf("got %d lines, expected %d", len(lines), len(tc.expected))
+			}
+			for i := range lines {
+				if lines[i] != tc.expected[i] {
+					t.Errorf("line %d: got %+q, expected %+q", i, lines[i], tc.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPlaceholderLimits(t *testing.T) {
+	p := kittyimg.Placeholder{ImageID: 1, Columns: 300, Rows: 300}
+	lines := p.Lines()
+	if len(lines) != kittyimg.MaxPlaceholderSize {
+		t.Fatalf("got %d lines, expected %d", len(lines), kittyimg.MaxPlaceholderSize)
+	}
+	if n := strings.Count(lines[0], "\U0010EEEE"); n != kittyimg.MaxPlaceholderSize {
+		t.Errorf("got %d cells, expected %d", n, kittyimg.MaxPlaceholderSize)
+	}
+
+	img := kittyimg.Image{ID: 1}
+	if lines := img.Placeholder(nil).Lines(); len(lines) != 0 {
+		t.Errorf("got %d lines, expected 0", len(lines))
+	}
+
+	for _, placement := range []kittyimg.Placement{
+		{Virtual: true, Columns: 298, Rows: 1},
+		{Virtual: true, Columns: 1, Rows: 298},
+		{Virtual: true, Columns: 0, Rows: 1},
+		{Virtual: true, Columns: 1},
+	} {
+		var buf bytes.Buffer
+		if err := img.Place(&buf, &placement); !errors.Is(err, kittyimg.ErrInvalidPlacement) {
+			t.Errorf("%+v: got %v, expected ErrInvalidPlacement", placement, err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%+v: unexpected output %q", placement, buf.String())
+		}
+	}
+}
+
+func TestImagePlaceVirtual(t *testing.T) {
+	img := kittyimg.Image{ID: 12}
+	var buf bytes.Buffer
+	if err := img.Place(&buf, &kittyimg.Placement{Virtual: true, Columns: 3, Rows: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := buf.String(), "\033_Gq=1,a=p,i=12,c=3,r=2,U=1;\033\\"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestEncodeVirtual(t *testing.T) {
+	enc := kittyimg.Encoder{Placement: &kittyimg.Placement{Virtual: true, Columns: 3, Rows: 2}}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, newTestImage(1, 1)); !errors.Is(err, kittyimg.ErrInvalidID) {
+		t.Errorf("got %v, expected ErrInvalidID", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"errors"
+	"image"
+	"io"
+	"strconv"
+)
+
+// Placement controls how an image is [displayed] on screen.
+//
+// The zero value displays the whole image at its native size at the cursor
+// position, then moves the cursor after the image. Zero fields are not sent to
+// the terminal, which then applies the protocol defaults.
+//
+// [displayed]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#controlling-displayed-image-layout
+type Placement struct {
+	// ID is the placement ID (p=). Displaying again the same image with the
+	// same placement ID replaces the previous placement.
+	ID uint32
+
+	// Source is the rectangle of the image to display (x=, y=, w=, h=), in
+	// pixels, relative to the top-left corner of the image. The whole image is
+	// displayed if Source is empty.
+	Source image.Rectangle
+
+	// XOffset and YOffset are the offset in pixels (X=, Y=) of the image
+	// inside the first cell. They must be smaller than the cell size.
+	XOffset, YOffset int
+
+	// Columns and Rows are the number of cells (c=, r=) over which the image
+	// is scaled. If only one is set, the other is computed to keep the aspect
+	// ratio.
+	Columns, Rows int
+
+	// Z is the z-index (z=) of the placement. Negative values draw the image
+	// under the text. Values below -1073741824 also draw it under cells with
+	// a non-default background color.
+	Z int32
+
+	// NoMove keeps the cursor at its position (C=1) instead of moving it
+	// after the image.
+	NoMove bool
+
+	// At, if not nil, is the cell (0-based column and row from the top-left
+	// corner of the screen) where the image is displayed instead of at the
+	// cursor. The cursor is saved, moved to the cell, and restored after the
+	// image, which is displayed with C=1.
+	At *image.Point
+
+	// ParentID, if not 0, makes the placement [relative] to the placement
+	// ParentPlacementID of the image ParentID (P=, Q=). The placement is
+	// displayed at ParentOffset cells (H=, V=) from the top-left corner of
+	// the parent, moves with it and is deleted with it. The cursor is not
+	// moved.
+	//
+	// Only a placement relative to itself is rejected here ([ErrCycle]), as
+	// the chain of parents is known only by the terminal, which rejects
+	// missing parents ([ErrNoParent]), cycles ([ErrCycle]) and chains too
+	// long ([ErrTooDeep], more than 8 relative placements with kitty). A
+	// virtual placement can be a parent, but can't be relative.
+	//
+	// [relative]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#relative-placements
+	ParentID, ParentPlacementID uint32
+	ParentOffset                image.Point
+
+	// Virtual makes a virtual placement (U=1), which is displayed where the
+	// cells of its [Placeholder] are printed instead of at the cursor.
+	// Columns and Rows must be set, from 1 to [MaxPlaceholderSize]. As
+	// placeholder cells refer to the image ID, the image must be transmitted
+	// with [Encoder.Transmit] and placed with [Image.Place].
+	Virtual bool
+}
+
+// ErrInvalidPlacement is returned for a [Placement] with inconsistent fields,
+// such as a relative placement without ParentID.
+var ErrInvalidPlacement = errors.New("kittyimg: invalid placement")
+
+// check validates the placement of the image id (0 if not known yet).
+//
+// Relative placements are checked only against themselves: placements are
+// not tracked, as they can be made by other programs, deleted or replaced on
+// the terminal side, so parents, cycles and the depth of chains are left to
+// the terminal, which replies ENOPARENT, ECYCLE or ETOODEEP.
+func (p *Placement) check(id uint32) error {
+	if p != nil && p.Virtual &&
+		(p.Columns < 1 || p.Columns > MaxPlaceholderSize || p.Rows < 1 || p.Rows > MaxPlaceholderSize) {
+		return ErrInvalidPlacement
+	}
+	if p == nil || (p.ParentID == 0 && p.ParentPlacementID == 0 && p.ParentOffset == image.Point{}) {
+		return nil
+	}
+	if p.ParentID == 0 || p.Virtual || p.At != nil {
+		return ErrInvalidPlacement
+	}
+	if p.ParentID == id && p.ID != 0 && p.ParentPlacementID == p.ID {
+		return ErrCycle
+	}
+	return nil
+}
+
+// displayAt calls display, which displays an image with the placement p,
+// with the cursor moved to the cell p.At if set. The cursor is saved before
+// (DECSC) and restored after (DECRC).
+func displayAt(w io.Writer, p *Placement, display func() error) error {
+	if p == nil || p.At == nil || p.Virtual {
+		return display()
+	}
+	move := append([]byte("\0337\033["), strconv.Itoa(p.At.Y+1)...)
+	move = append(move, ';')
+	move = strconv.AppendInt(move, int64(p.At.X+1), 10)
+	move = append(move, 'H')
+	if _, err := w.Write(move); err != nil {
+		return err
+	}
+	if err := display(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\0338")
+	return err
+}
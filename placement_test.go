@@ -0,0 +1,180 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestEncodePlacement(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		placement *kittyimg.Placement
+		expected  string
+	}{
+		{"nil", nil, "a=T,f=24,o=z,q=1,s=4,t=d,v=4"},
+		{"zero", &kittyimg.Placement{}, "a=T,f=24,o=z,q=1,s=4,t=d,v=4"},
+		{"cells", &kittyimg.Placement{Columns: 10, Rows: 5}, "a=T,c=10,f=24,o=z,q=1,r=5,s=4,t=d,v=4"},
+		{"source", &kittyimg.Placement{Source: image.Rect(1, 2, 3, 4)}, "a=T,f=24,h=2,o=z,q=1,s=4,t=d,v=4,w=2,x=1,y=2"},
+		{"offset", &kittyimg.Placement{XOffset: 3, YOffset: 5}, "X=3,Y=5,a=T,f=24,o=z,q=1,s=4,t=d,v=4"},
+		{"under-text", &kittyimg.Placement{Z: -1, NoMove: true}, "C=1,a=T,f=24,o=z,q=1,s=4,t=d,v=4,z=-1"},
+		{"id", &kittyimg.Placement{ID: 3, Z: 2}, "a=T,f=24,o=z,p=3,q=1,s=4,t=d,v=4,z=2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			enc := kittyimg.Encoder{Placement: tc.placement}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, newTestImage(4, 4)); err != nil {
+				t.Fatal(err)
+			}
+			n := 0
+			for bl := range extractBlocks(buf.Bytes()) {
+				if got := bl.Params.String(); got != tc.expected {
+					t.Errorf("got %q, expected %q", got, tc.expected)
+				}
+				n++
+			}
+			if n != 1 {
+				t.Errorf("got %d blocks", n)
+			}
+		})
+	}
+}
+
+func TestImagePlace(t *testing.T) {
+	img := kittyimg.Image{ID: 12}
+	var buf bytes.Buffer
+	if err := img.Place(&buf, &kittyimg.Placement{Columns: 2, NoMove: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := buf.String(), "\033_Gq=1,a=p,i=12,c=2,C=1;\033\\"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestEncodePlacementAt(t *testing.T) {
+	enc := kittyimg.Encoder{
+		Placement: &kittyimg.Placement{At: &image.Point{X: 3, Y: 5}},
+		// Ignored
+		Align:  kittyimg.AlignRight,
+		Window: &kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480},
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, newTestImage(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, "\0337\033[6;4H\033_G") || !strings.HasSuffix(out, "\033\\\0338") {
+		t.Fatalf("got %q", out)
+	}
+	out = strings.TrimSuffix(strings.TrimPrefix(out, "\0337\033[6;4H"), "\0338")
+	for bl := range extractBlocks([]byte(out)) {
+		if got, expected := bl.Params.String(), "C=1,a=T,f=24,o=z,q=1,s=4,t=d,v=4"; got != expected {
+			t.Errorf("got %q, expected %q", got, expected)
+		}
+	}
+
+	buf.Reset()
+	img := kittyimg.Image{ID: 12}
+	if err := img.Place(&buf, &kittyimg.Placement{At: &image.Point{}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := buf.String(), "\0337\033[1;1H\033_Gq=1,a=p,i=12,C=1;\033\\\0338"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestImagePlaceRelative(t *testing.T) {
+	img := kittyimg.Image{ID: 7}
+	for _, tc := range []struct {
+		name      string
+		placement kittyimg.Placement
+		expected  string
+		err       error
+	}{
+		{"parent", kittyimg.Placement{ParentID: 3}, "P=3,a=p,i=7,q=1", nil},
+		{"parent-placement", kittyimg.Placement{ID: 2, ParentID: 3, ParentPlacementID: 4}, "P=3,Q=4,a=p,i=7,p=2,q=1", nil},
+		{"offset", kittyimg.Placement{ParentID: 3, ParentOffset: image.Pt(-2, 5)}, "H=-2,P=3,V=5,a=p,i=7,q=1", nil},
+		{"same-image", kittyimg.Placement{ID: 1, ParentID: 7, ParentPlacementID: 2}, "P=7,Q=2,a=p,i=7,p=1,q=1", nil},
+		{"no-parent-id", kittyimg.Placement{ParentPlacementID: 4}, "", kittyimg.ErrInvalidPlacement},
+		{"no-parent-offset", kittyimg.Placement{ParentOffset: image.Pt(1, 0)}, "", kittyimg.ErrInvalidPlacement},
+		{"virtual", kittyimg.Placement{Virtual: true, Columns: 1, Rows: 1, ParentID: 3}, "", kittyimg.ErrInvalidPlacement},
+		{"at", kittyimg.Placement{At: &image.Point{}, ParentID: 3}, "", kittyimg.ErrInvalidPlacement},
+		{"cycle", kittyimg.Placement{ID: 2, ParentID: 7, ParentPlacementID: 2}, "", kittyimg.ErrCycle},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := img.Place(&buf, &tc.placement)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got error %v, expected %v", err, tc.err)
+			}
+			if err != nil {
+				if buf.Len() != 0 {
+					t.Errorf("unexpected output %q", buf.String())
+				}
+				return
+			}
+			m := blockRE.FindStringSubmatch(buf.String())
+			if m == nil || len(m[0]) != buf.Len() {
+				t.Fatalf("invalid command %q", buf.String())
+			}
+			params := m[blockRE.SubexpIndex("params")]
+			if !kvRE.MatchString(params) {
+				t.Fatalf("invalid control data %q", params)
+			}
+			if got := parseParams(params).String(); got != tc.expected {
+				t.Errorf("got %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEncodeRelative(t *testing.T) {
+	enc := kittyimg.Encoder{
+		Placement: &kittyimg.Placement{ParentID: 3, ParentOffset: image.Pt(1, 1)},
+		// Ignored
+		Align:  kittyimg.AlignCenter,
+		Window: &kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480},
+	}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, newTestImage(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	params, _ := decodeImage(t, buf.Bytes())
+	if got, expected := params.String(), "H=1,P=3,V=1,a=T,f=24,o=z,q=1,s=4,t=d,v=4"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	enc.Placement = &kittyimg.Placement{ParentPlacementID: 1}
+	buf.Reset()
+	if err := enc.Encode(&buf, newTestImage(4, 4)); !errors.Is(err, kittyimg.ErrInvalidPlacement) {
+		t.Errorf("got %v, expected ErrInvalidPlacement", err)
+	}
+	if _, err := enc.EncodeAnimation(&buf, 0, &kittyimg.Animation{Frames: []kittyimg.Frame{{Image: newTestImage(4, 4)}}}); !errors.Is(err, kittyimg.ErrInvalidPlacement) {
+		t.Errorf("got %v, expected ErrInvalidPlacement", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,59 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+)
+
+// PlaygroundEncoder displays images in the output of the [Go Playground],
+// which shows as an image a line with the "IMAGE:" prefix followed by the
+// base64 encoded image file. Images are sent as PNG.
+//
+// Fprint and Fprintln use PlaygroundEncoder if the environment variable
+// KITTYIMG_PROTOCOL (see [ProtocolEnv]) is "playground". In the Playground,
+// it can be set by the program with [os.Setenv].
+//
+// [Go Playground]: https://go.dev/play
+type PlaygroundEncoder struct {
+	// Fit and ScaleUp resize images like [Encoder.Fit] and [Encoder.ScaleUp].
+	Fit     image.Point
+	ScaleUp bool
+
+	buf bytes.Buffer
+}
+
+// Encode writes img on a single line, without the final '\n' (see [Fprintln]).
+func (enc *PlaygroundEncoder) Encode(w io.Writer, img image.Image) error {
+	img = resize(img, enc.Fit, enc.ScaleUp)
+	enc.buf.Reset()
+	enc.buf.WriteString("IMAGE:")
+	b64 := base64.NewEncoder(base64.StdEncoding, &enc.buf)
+	if err := png.Encode(b64, img); err != nil {
+		return err
+	}
+	if err := b64.Close(); err != nil {
+		return err
+	}
+	// A single write, as the Playground checks the prefix of each write
+	_, err := w.Write(enc.buf.Bytes())
+	return err
+}
@@ -0,0 +1,100 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// decodePlayground decodes the image written for the Go Playground.
+func decodePlayground(t *testing.T, s string) image.Image {
+	t.Helper()
+	data, ok := strings.CutPrefix(s, "IMAGE:")
+	if !ok {
+		t.Fatalf("invalid prefix: %q", s[:min(len(s), 20)])
+	}
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestPlaygroundEncode(t *testing.T) {
+	img := newTestImage(5, 3)
+	var enc kittyimg.PlaygroundEncoder
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	got := decodePlayground(t, buf.String())
+	if got.Bounds() != img.Bounds() {
+		t.Fatalf("got bounds %v, expected %v", got.Bounds(), img.Bounds())
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			if c := color.NRGBAModel.Convert(got.At(x, y)); c != img.At(x, y) {
+				t.Fatalf("pixel (%d,%d): got %v, expected %v", x, y, c, img.At(x, y))
+			}
+		}
+	}
+
+	enc.Fit = image.Pt(2, 0)
+	buf.Reset()
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if size := decodePlayground(t, buf.String()).Bounds().Size(); size != image.Pt(2, 1) {
+		t.Errorf("got size %v, expected 2x1", size)
+	}
+}
+
+func TestFprintlnPlayground(t *testing.T) {
+	t.Setenv(kittyimg.ProtocolEnv, "playground")
+	var buf bytes.Buffer
+	if err := kittyimg.Fprintln(&buf, newTestImage(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := strings.CutSuffix(buf.String(), "\n")
+	if !ok || strings.Contains(s, "\n") {
+		t.Fatalf("a single line expected: %q", buf.String())
+	}
+	decodePlayground(t, s)
+
+	t.Setenv(kittyimg.ProtocolEnv, "")
+	buf.Reset()
+	if err := kittyimg.Fprintln(&buf, newTestImage(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "\033_G") {
+		t.Errorf("kitty's protocol expected: %q", buf.String()[:min(buf.Len(), 20)])
+	}
+}
@@ -23,7 +23,9 @@ import (
 	"bytes"
 	"fmt"
 	"image"
+	"image/gif"
 	"io"
+	"os"
 )
 
 // Encoder is an [image.Image] encoder, like [image/png.Encoder].
@@ -31,58 +33,162 @@ import (
 // Using an Encoder explicitely allows to reuse resources (memory buffers) when encoding
 // multiple images sequentially.
 type Encoder struct {
+	// Placement, if not nil, controls the layout of images displayed by
+	// Encode and Transcode.
+	Placement *Placement
+
+	// Format is the pixel format used to transmit images. The default,
+	// FormatAuto, uses RGB for opaque images to reduce the payload size.
+	Format Format
+
+	// Fit, if not zero, is the size in pixels of the box in which images
+	// must fit. Larger images are scaled down with a Catmull-Rom filter,
+	// preserving the aspect ratio. A zero width or height is not constrained.
+	// See [GetWindowSize] to fit images in the terminal window.
+	Fit image.Point
+
+	// ScaleUp enlarges images smaller than Fit to fill it.
+	ScaleUp bool
+
+	// Align is the horizontal alignment of images displayed by Encode and
+	// Transcode in the terminal window, which requires Window. With
+	// AlignCenter or AlignRight, the cursor is first moved to the start of
+	// the line. Align is ignored if Placement.At or Placement.ParentID is set.
+	Align Align
+
+	// Window is the size of the terminal window, used for alignment.
+	// See [GetWindowSize].
+	Window *WindowSize
+
+	// Medium is the transmission medium of image data. The default,
+	// MediumDirect, sends data in the escape codes.
+	Medium Medium
+
+	// File, if not nil, is the regular file to which data is appended with
+	// MediumFile. The data of each image is referenced by its offset (O=)
+	// and size (S=), so the file must not be truncated before the terminal
+	// has read it.
+	File *os.File
+
+	// TTY is the terminal probed by MediumAuto.
+	TTY *os.File
+
 	pw  zlibPayloadWriter
 	buf []byte
+	cmd []byte // control data
+
+	probed     bool   // MediumAuto has been resolved
+	autoMedium Medium // the medium selected by MediumAuto
 }
 
 // Encode [encodes] img and writes the result on w.
 //
 // [encodes]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#display-images-on-screen
 func (enc *Encoder) Encode(w io.Writer, img image.Image) error {
+	if err := enc.checkPlacement(); err != nil {
+		return err
+	}
+	return displayAt(w, enc.Placement, func() error {
+		return enc.encode(w, img, 'T', 0)
+	})
+}
+
+// checkPlacement validates the placement of images displayed at the cursor.
+func (enc *Encoder) checkPlacement() error {
+	if enc.Placement == nil {
+		return nil
+	}
+	if enc.Placement.Virtual {
+		return ErrInvalidID
+	}
+	return enc.Placement.check(0)
+}
+
+// encode transmits img with the given action (a=T or a=t) and image ID (i=, omitted if 0).
+func (enc *Encoder) encode(w io.Writer, img image.Image, action byte, id uint32) error {
+	img = enc.resize(img)
 	bounds := img.Bounds()
+	format := enc.format(img)
+
+	var p *Placement
+	if action == 'T' {
+		var err error
+		if p, err = enc.align(w, bounds.Size()); err != nil {
+			return err
+		}
+	}
 
-	// f=32 => RGBA
-	_, err := fmt.Fprintf(w, "\033_Gq=1,a=T,f=32,s=%d,v=%d,t=d", bounds.Dx(), bounds.Dy())
+	cmd := appendCommand(enc.cmd[:0], action, id)
+	// f=24 => RGB, f=32 => RGBA
+	cmd = appendKeyInt(cmd, 'f', int(format))
+	cmd = appendKeyInt(cmd, 's', bounds.Dx())
+	cmd = appendKeyInt(cmd, 'v', bounds.Dy())
+	if action == 'T' {
+		cmd = appendPlacement(cmd, p)
+	}
+	return enc.send(w, cmd, img, format)
+}
+
+// send writes the control data cmd followed by the pixels of img as a
+// compressed payload, or as a path to the data with media other than
+// MediumDirect.
+func (enc *Encoder) send(w io.Writer, cmd []byte, img image.Image, format Format) error {
+	if enc.medium() != MediumDirect {
+		return enc.sendFile(w, cmd, func(f io.Writer) error {
+			return enc.writePixels(f, img, format)
+		})
+	}
+
+	cmd = appendKeyChar(cmd, 't', 'd')
+	enc.cmd = cmd
+	_, err := w.Write(cmd)
 	if err != nil {
 		return err
 	}
 
 	enc.pw.Reset(w)
+	if err = enc.writePixels(&enc.pw, img, format); err != nil {
+		return err
+	}
+	return enc.pw.Close()
+}
 
-	bufCap := min(bounds.Dx()*bounds.Dy()*4, 16384) // Multiple of 4 (RGBA)
-	buf := enc.buf
-	if cap(enc.buf) < bufCap {
-		buf = make([]byte, 0, bufCap)
-		enc.buf = buf
-	} else {
-		buf = buf[:0]
+// sendRaw writes the command cmd followed by the payload read from r,
+// without compression, or as a path to the data with media other than
+// MediumDirect.
+func (enc *Encoder) sendRaw(w io.Writer, cmd []byte, r io.Reader) error {
+	if enc.medium() != MediumDirect {
+		return enc.sendFile(w, cmd, func(f io.Writer) error {
+			_, err := io.Copy(f, r)
+			return err
+		})
 	}
 
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			if len(buf) == cap(buf) {
-				if _, err = enc.pw.Write(buf); err != nil {
-					return err
-				}
-				buf = buf[:0]
-			}
-			r, g, b, a := img.At(x, y).RGBA()
-			// A color's RGBA method returns values in the range [0, 65535].
-			// Shifting by 8 reduces this to the range [0, 255].
-			buf = append(buf, byte(r>>8), byte(g>>8), byte(b>>8), byte(a>>8))
-		}
+	enc.cmd = cmd
+	if _, err := w.Write(cmd); err != nil {
+		return err
 	}
 
-	if _, err = enc.pw.Write(buf); err != nil {
+	var pw *payloadWriter = &enc.pw.pw
+	pw.Reset(w)
+
+	if _, err := io.Copy(pw, r); err != nil {
 		return err
 	}
-	return enc.pw.Close()
+	return pw.Close()
 }
 
 // Fprint [encodes] img and writes the result on w.
 //
+// If the environment variable [ProtocolEnv] is "playground", img is written
+// for the Go Playground with [PlaygroundEncoder].
+//
 // [encodes]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#display-images-on-screen
 func Fprint(w io.Writer, img image.Image) error {
+	if os.Getenv(ProtocolEnv) == protocolNames[ProtocolPlayground] {
+		var e PlaygroundEncoder
+		return e.Encode(w, img)
+	}
 	var e Encoder
 	return e.Encode(w, img)
 }
@@ -102,37 +208,98 @@ func Fprintln(w io.Writer, img image.Image) error {
 //
 // The supported input image formats depend on the formats registered with the [image]
 // framework (see [image/png], [image/gif], [image/jpeg]).
+//
+// An animated GIF or an animated PNG (APNG) is transmitted as an [animation]
+// which is started once all frames are transmitted. APNG frames are sent as
+// PNG data, without decoding, unless the image must be resized to [Encoder.Fit].
+//
+// [animation]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#animation
 func (enc *Encoder) Transcode(w io.Writer, r io.Reader) error {
+	if err := enc.checkPlacement(); err != nil {
+		return err
+	}
+	return displayAt(w, enc.Placement, func() error {
+		_, err := enc.transcode(w, r, 'T', 0)
+		return err
+	})
+}
+
+// transcode transmits the image file read from r with the given action (a=T or a=t)
+// and image ID (i=, omitted if 0). It returns the configuration (size in pixels) of the image.
+func (enc *Encoder) transcode(w io.Writer, r io.Reader, action byte, id uint32) (image.Config, error) {
+	src, srcOffset := enc.sourceFile(r)
+
 	var buf bytes.Buffer
 	in := io.TeeReader(r, &buf)
 	cfg, format, err := image.DecodeConfig(in)
 	if err != nil {
-		return readError(r, err)
+		return cfg, readError(r, err)
 	}
 	// Restart from byte 0
 	in = io.MultiReader(&buf, r)
 
-	// For PNG we send the raw file that probably has better compression
+	size := enc.fitSize(image.Pt(cfg.Width, cfg.Height))
+	resized := size != image.Pt(cfg.Width, cfg.Height)
+	cfg.Width, cfg.Height = size.X, size.Y
+
+	// For PNG we send the raw file that probably has better compression,
+	// unless it must be resized
 	// https://sw.kovidgoyal.net/kitty/graphics-protocol/#png-data
 	if format == "png" {
-		if _, err = fmt.Fprintf(w, "\033_Gq=1,a=T,f=100,s=%d,v=%d", cfg.Width, cfg.Height); err != nil {
-			return err
+		// An APNG is transmitted as an animation
+		var head bytes.Buffer
+		animated, err := isAPNG(io.TeeReader(in, &head))
+		if err != nil {
+			return cfg, readError(r, err)
 		}
+		in = io.MultiReader(&head, in)
+		if animated {
+			data, err := io.ReadAll(in)
+			if err != nil {
+				return cfg, readError(r, err)
+			}
+			a, err := parseAPNG(data)
+			if err != nil {
+				return cfg, readError(r, err)
+			}
+			return cfg, enc.encodeAPNG(w, a, action, id)
+		}
+		if !resized {
+			var p *Placement
+			if action == 'T' {
+				if p, err = enc.align(w, size); err != nil {
+					return cfg, err
+				}
+			}
+			cmd := appendCommand(enc.cmd[:0], action, id)
+			cmd = appendKeyInt(cmd, 'f', 100)
+			cmd = appendKeyInt(cmd, 's', cfg.Width)
+			cmd = appendKeyInt(cmd, 'v', cfg.Height)
+			if action == 'T' {
+				cmd = appendPlacement(cmd, p)
+			}
+			if src != "" {
+				// The terminal reads the source file
+				return cfg, enc.sendPath(w, appendFileMedium(cmd, srcOffset, 0), src)
+			}
+			return cfg, enc.sendRaw(w, cmd, in)
+		}
+	}
 
-		var pw *payloadWriter = &enc.pw.pw
-		pw.Reset(w)
-
-		if _, err = io.Copy(pw, in); err != nil {
-			return err
+	// An animated GIF is transmitted as an animation
+	if format == "gif" {
+		g, err := gif.DecodeAll(in)
+		if err != nil {
+			return cfg, readError(r, err)
 		}
-		return pw.Close()
+		return cfg, enc.encodeGIF(w, g, action, id)
 	}
 
 	img, _, err := image.Decode(in)
 	if err != nil {
-		return readError(r, err)
+		return cfg, readError(r, err)
 	}
-	return enc.Encode(w, img)
+	return cfg, enc.encode(w, img, action, id)
 }
 
 // Transcode transforms the image file into the Kitty protocol representation for display
@@ -0,0 +1,227 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"context"
+	"image"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Renderer displays images on a terminal. It is implemented by [Encoder]
+// (kitty's graphics protocol), [SixelEncoder], [ITermEncoder], [TextEncoder]
+// and [PlaygroundEncoder].
+//
+// See [NewRenderer] to select the best renderer for the terminal.
+type Renderer interface {
+	// Encode displays img at the cursor position.
+	Encode(w io.Writer, img image.Image) error
+}
+
+var (
+	_ Renderer = (*Encoder)(nil)
+	_ Renderer = (*SixelEncoder)(nil)
+	_ Renderer = (*ITermEncoder)(nil)
+	_ Renderer = (*TextEncoder)(nil)
+	_ Renderer = (*PlaygroundEncoder)(nil)
+)
+
+// Protocol is a way to display images on a terminal.
+type Protocol int
+
+const (
+	// ProtocolText renders images as text ([TextEncoder]).
+	ProtocolText Protocol = iota
+	// ProtocolKitty is kitty's graphics protocol ([Encoder]).
+	ProtocolKitty
+	// ProtocolSixel is sixel graphics ([SixelEncoder]).
+	ProtocolSixel
+	// ProtocolITerm is the inline images protocol of iTerm2 ([ITermEncoder]).
+	ProtocolITerm
+	// ProtocolPlayground is the IMAGE: output of the Go Playground
+	// ([PlaygroundEncoder]).
+	ProtocolPlayground
+)
+
+// ProtocolEnv is the environment variable that overrides the protocol
+// selected by [DetectProtocol] with the name of a [Protocol] ("text",
+// "kitty", "sixel", "iterm" or "playground").
+const ProtocolEnv = "KITTYIMG_PROTOCOL"
+
+var protocolNames = [...]string{
+	ProtocolText:       "text",
+	ProtocolKitty:      "kitty",
+	ProtocolSixel:      "sixel",
+	ProtocolITerm:      "iterm",
+	ProtocolPlayground: "playground",
+}
+
+// String returns the name of the protocol.
+func (p Protocol) String() string {
+	if p < 0 || int(p) >= len(protocolNames) {
+		return "Protocol(" + strconv.Itoa(int(p)) + ")"
+	}
+	return protocolNames[p]
+}
+
+// xtversionRequest requests the name and version of the terminal (XTVERSION).
+const xtversionRequest = "\033[>0q"
+
+// xtversionReplyRE matches the reply to XTVERSION: DCS > | text ST.
+var xtversionReplyRE = regexp.MustCompile("\033P>\\|([^\033]*)\033\\\\")
+
+// DetectProtocol returns the best protocol to display images on the terminal
+// tty.
+//
+// The protocol named by the environment variable [ProtocolEnv] is returned if
+// set. Environment variables set by terminals are checked next: KITTY_WINDOW_ID,
+// GHOSTTY_RESOURCES_DIR and TERM (xterm-kitty, xterm-ghostty) select
+// ProtocolKitty, WEZTERM_EXECUTABLE and TERM_PROGRAM (iTerm.app, WezTerm,
+// vscode) select ProtocolITerm. Otherwise, if tty is a terminal, it is
+// queried like with [Detect], with the terminal name (XTVERSION) and the
+// primary device attributes (DA1), which report sixel support. ProtocolText
+// is returned if nothing else is supported.
+//
+// If ctx has no deadline, [DetectTimeout] is applied to the query.
+func DetectProtocol(ctx context.Context, tty *os.File) (Protocol, error) {
+	if p, ok := envProtocol(); ok {
+		return p, nil
+	}
+	if tty == nil {
+		return ProtocolText, nil
+	}
+
+	b, da, err := exchange(ctx, tty, []byte(queryDirect+xtversionRequest))
+	if err == errNotTerminal {
+		return ProtocolText, nil
+	}
+	if err != nil {
+		return ProtocolText, err
+	}
+	if m := xtversionReplyRE.FindSubmatch(b); m != nil {
+		// WezTerm's support of kitty's graphics protocol is incomplete
+		if name := string(m[1]); strings.HasPrefix(name, "iTerm2") || strings.HasPrefix(name, "WezTerm") {
+			return ProtocolITerm, nil
+		}
+	}
+	if resp, _ := findResponse(b); resp != nil {
+		return ProtocolKitty, nil
+	}
+	if hasSixel(da) {
+		return ProtocolSixel, nil
+	}
+	return ProtocolText, nil
+}
+
+// envProtocol returns the protocol supported by the terminal identified by
+// environment variables.
+func envProtocol() (Protocol, bool) {
+	if name := os.Getenv(ProtocolEnv); name != "" {
+		for p, n := range protocolNames {
+			if n == name {
+				return Protocol(p), true
+			}
+		}
+	}
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "",
+		os.Getenv("GHOSTTY_RESOURCES_DIR") != "",
+		os.Getenv("TERM") == "xterm-kitty",
+		os.Getenv("TERM") == "xterm-ghostty":
+		return ProtocolKitty, true
+	case os.Getenv("WEZTERM_EXECUTABLE") != "":
+		return ProtocolITerm, true
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode":
+		return ProtocolITerm, true
+	case "ghostty":
+		return ProtocolKitty, true
+	}
+	return ProtocolText, false
+}
+
+// hasSixel reports whether the reply to DA1 (CSI ? Ps ; ... c) has the
+// attribute 4 (sixel graphics).
+func hasSixel(da []byte) bool {
+	params, ok := strings.CutPrefix(string(da), "\033[?")
+	if !ok {
+		return false
+	}
+	for _, p := range strings.Split(strings.TrimSuffix(params, "c"), ";") {
+		if p == "4" {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRenderer returns the renderer of the best protocol to display images on
+// the terminal tty, selected with [DetectProtocol]. tty may be nil if the
+// output is not a terminal.
+//
+// The text renderer uses the colors reported by [DetectTextColors] and fits
+// images in the width of the terminal (80 columns if tty is nil).
+//
+// [Fprint] and [Transcode] use kitty's graphics protocol (except for
+// ProtocolPlayground selected by [ProtocolEnv], with Fprint).
+func NewRenderer(ctx context.Context, tty *os.File) (Renderer, error) {
+	p, err := DetectProtocol(ctx, tty)
+	if err != nil {
+		return nil, err
+	}
+	switch p {
+	case ProtocolKitty:
+		return &Encoder{}, nil
+	case ProtocolSixel:
+		return &SixelEncoder{}, nil
+	case ProtocolITerm:
+		return &ITermEncoder{}, nil
+	case ProtocolPlayground:
+		return &PlaygroundEncoder{}, nil
+	}
+	enc := &TextEncoder{
+		Colors: DetectTextColors(),
+		Fit:    image.Pt(80, 0),
+	}
+	if tty != nil {
+		if fd, err := terminalFd(tty); err == nil {
+			if ws, err := getWinsize(fd); err == nil && ws.Columns > 0 {
+				enc.Fit.X = ws.Columns
+			}
+		}
+	}
+	return enc, nil
+}
+
+// DetectTextColors returns the colors supported by the terminal for
+// [TextEncoder], from the environment variables COLORTERM (truecolor or
+// 24bit) and TERM (*256color*).
+func DetectTextColors() TextColors {
+	switch colorTerm := os.Getenv("COLORTERM"); {
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return TextTrueColor
+	case strings.Contains(os.Getenv("TERM"), "256color"):
+		return Text256Colors
+	default:
+		return Text16Colors
+	}
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestDetectProtocolQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reply    string
+		expected kittyimg.Protocol
+	}{
+		{"kitty", "\033_Gi=31;OK\033\\\033P>|kitty(0.40.0)\033\\\033[?62;c", kittyimg.ProtocolKitty},
+		{"iterm2", "\033P>|iTerm2 3.5.0\033\\\033[?62;4c", kittyimg.ProtocolITerm},
+		{"wezterm", "\033_Gi=31;OK\033\\\033P>|WezTerm 20240203\033\\\033[?65;4;6;18;22c", kittyimg.ProtocolITerm},
+		{"xterm-sixel", "\033P>|XTerm(390)\033\\\033[?63;1;2;4;6;9;15;16;22;28c", kittyimg.ProtocolSixel},
+		{"foot", "\033[?62;4;22c", kittyimg.ProtocolSixel},
+		{"vt100", "\033[?1;2c", kittyimg.ProtocolText},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearTerminalEnv(t)
+			master, tty := openPTY(t)
+			received := fakeTerminal(t, master, tc.reply)
+
+			p, err := kittyimg.DetectProtocol(context.Background(), tty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tc.expected {
+				t.Errorf("got %v, expected %v", p, tc.expected)
+			}
+			q := <-received
+			if !bytes.Contains(q, []byte("a=q,")) || !bytes.Contains(q, []byte("\033[>0q")) {
+				t.Errorf("queries not sent: %q", q)
+			}
+		})
+	}
+}
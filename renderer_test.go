@@ -0,0 +1,110 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// clearTerminalEnv unsets the environment variables that identify the terminal.
+func clearTerminalEnv(t *testing.T) {
+	for _, name := range []string{
+		"KITTY_WINDOW_ID", "GHOSTTY_RESOURCES_DIR", "WEZTERM_EXECUTABLE",
+		"TERM", "TERM_PROGRAM", "COLORTERM", kittyimg.ProtocolEnv,
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestDetectProtocolEnv(t *testing.T) {
+	for _, tc := range []struct {
+		name, value string
+		expected    kittyimg.Protocol
+	}{
+		{"KITTY_WINDOW_ID", "1", kittyimg.ProtocolKitty},
+		{"GHOSTTY_RESOURCES_DIR", "/usr/share/ghostty", kittyimg.ProtocolKitty},
+		{"TERM", "xterm-kitty", kittyimg.ProtocolKitty},
+		{"TERM", "xterm-ghostty", kittyimg.ProtocolKitty},
+		{"TERM_PROGRAM", "ghostty", kittyimg.ProtocolKitty},
+		{"WEZTERM_EXECUTABLE", "/usr/bin/wezterm-gui", kittyimg.ProtocolITerm},
+		{"TERM_PROGRAM", "iTerm.app", kittyimg.ProtocolITerm},
+		{"TERM_PROGRAM", "WezTerm", kittyimg.ProtocolITerm},
+		{"TERM_PROGRAM", "vscode", kittyimg.ProtocolITerm},
+		{"TERM", "xterm-256color", kittyimg.ProtocolText},
+		{kittyimg.ProtocolEnv, "sixel", kittyimg.ProtocolSixel},
+		{kittyimg.ProtocolEnv, "playground", kittyimg.ProtocolPlayground},
+		{kittyimg.ProtocolEnv, "unknown", kittyimg.ProtocolText},
+	} {
+		t.Run(tc.name+"="+tc.value, func(t *testing.T) {
+			clearTerminalEnv(t)
+			t.Setenv(tc.name, tc.value)
+			p, err := kittyimg.DetectProtocol(context.Background(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p != tc.expected {
+				t.Errorf("got %v, expected %v", p, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewRenderer(t *testing.T) {
+	clearTerminalEnv(t)
+	t.Setenv("TERM", "xterm-256color")
+	r, err := kittyimg.NewRenderer(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, ok := r.(*kittyimg.TextEncoder)
+	if !ok {
+		t.Fatalf("got %T, expected *kittyimg.TextEncoder", r)
+	}
+	if enc.Colors != kittyimg.Text256Colors || enc.Fit.X != 80 {
+		t.Errorf("got %+v", enc)
+	}
+
+	t.Setenv("KITTY_WINDOW_ID", "1")
+	if r, err = kittyimg.NewRenderer(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.(*kittyimg.Encoder); !ok {
+		t.Errorf("got %T, expected *kittyimg.Encoder", r)
+	}
+}
+
+func TestDetectTextColors(t *testing.T) {
+	for _, tc := range []struct {
+		colorTerm, term string
+		expected        kittyimg.TextColors
+	}{
+		{"truecolor", "xterm-256color", kittyimg.TextTrueColor},
+		{"24bit", "", kittyimg.TextTrueColor},
+		{"", "screen-256color", kittyimg.Text256Colors},
+		{"", "linux", kittyimg.Text16Colors},
+		{"", "", kittyimg.Text16Colors},
+	} {
+		t.Setenv("COLORTERM", tc.colorTerm)
+		t.Setenv("TERM", tc.term)
+		if got := kittyimg.DetectTextColors(); got != tc.expected {
+			t.Errorf("COLORTERM=%q TERM=%q: got %d, expected %d", tc.colorTerm, tc.term, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// fitSize returns the size of an image of the given size scaled to fit in
+// box, preserving the aspect ratio. A zero width or height of box is not
+// constrained. Images smaller than box are enlarged only if scaleUp.
+func fitSize(size, box image.Point, scaleUp bool) image.Point {
+	if size.X <= 0 || size.Y <= 0 || box == (image.Point{}) {
+		return size
+	}
+	scale := 0.0
+	if box.X > 0 {
+		scale = float64(box.X) / float64(size.X)
+	}
+	if box.Y > 0 {
+		if s := float64(box.Y) / float64(size.Y); scale == 0 || s < scale {
+			scale = s
+		}
+	}
+	if scale == 1 || (scale > 1 && !scaleUp) {
+		return size
+	}
+	return image.Pt(
+		max(1, int(float64(size.X)*scale+0.5)),
+		max(1, int(float64(size.Y)*scale+0.5)),
+	)
+}
+
+// fitSize returns the size of an image of the given size once resized to
+// [Encoder.Fit].
+func (enc *Encoder) fitSize(size image.Point) image.Point {
+	return fitSize(size, enc.Fit, enc.ScaleUp)
+}
+
+// resize returns img resized to [Encoder.Fit], or img itself if it already
+// has the right size.
+func (enc *Encoder) resize(img image.Image) image.Image {
+	return resize(img, enc.Fit, enc.ScaleUp)
+}
+
+// resize returns img resized to fit in box (see [fitSize]), or img itself
+// if it already has the right size.
+func resize(img image.Image, box image.Point, scaleUp bool) image.Image {
+	bounds := img.Bounds()
+	size := fitSize(bounds.Size(), box, scaleUp)
+	if size == bounds.Size() {
+		return img
+	}
+	return scale(img, size)
+}
+
+// scale returns img scaled to size with a Catmull-Rom filter.
+func scale(img image.Image, size image.Point) *image.RGBA {
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	draw.CatmullRom.Scale(dst, dst.Rect, img, img.Bounds(), draw.Src, nil)
+	return dst
+}
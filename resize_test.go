@@ -0,0 +1,170 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"os"
+	"strconv"
+	"testing"
+
+	_ "image/png"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestEncodeFit(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fit      image.Point
+		scaleUp  bool
+		expected image.Point
+	}{
+		{"none", image.Point{}, false, image.Pt(200, 100)},
+		{"width", image.Pt(50, 0), false, image.Pt(50, 25)},
+		{"height", image.Pt(0, 50), false, image.Pt(100, 50)},
+		{"box", image.Pt(100, 100), false, image.Pt(100, 50)},
+		{"larger", image.Pt(400, 400), false, image.Pt(200, 100)},
+		{"scale-up", image.Pt(400, 400), true, image.Pt(400, 200)},
+		{"tiny", image.Pt(1, 1), false, image.Pt(1, 1)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			enc := kittyimg.Encoder{Fit: tc.fit, ScaleUp: tc.scaleUp}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, newTestImage(200, 100)); err != nil {
+				t.Fatal(err)
+			}
+			params, pix := decodeImage(t, buf.Bytes())
+			if got := params['s'] + "x" + params['v']; got != strconv.Itoa(tc.expected.X)+"x"+strconv.Itoa(tc.expected.Y) {
+				t.Errorf("got %s, expected %v", got, tc.expected)
+			}
+			if len(pix) != tc.expected.X*tc.expected.Y*3 {
+				t.Errorf("got %d bytes of pixels", len(pix))
+			}
+		})
+	}
+}
+
+func TestEncodeFitColor(t *testing.T) {
+	c := color.NRGBA{0x12, 0x80, 0xf0, 0x80}
+	src := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+	for i := 0; i < len(src.Pix); i += 4 {
+		src.Pix[i], src.Pix[i+1], src.Pix[i+2], src.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+
+	enc := kittyimg.Encoder{Fit: image.Pt(7, 7)}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	params, pix := decodeImage(t, buf.Bytes())
+	if params['s'] != "7" || params['v'] != "5" || params['f'] != "32" {
+		t.Fatalf("got %v", params)
+	}
+	// Straight alpha is preserved by the filter, within rounding errors
+	for i := 0; i < len(pix); i += 4 {
+		for j, expected := range []uint8{c.R, c.G, c.B, c.A} {
+			if d := int(pix[i+j]) - int(expected); d < -2 || d > 2 {
+				t.Fatalf("pixel %d: got %x, expected %x", i/4, pix[i:i+4], []uint8{c.R, c.G, c.B, c.A})
+			}
+		}
+	}
+}
+
+func TestTransmitFit(t *testing.T) {
+	enc := kittyimg.Encoder{Fit: image.Pt(8, 8)}
+	var buf bytes.Buffer
+	img, err := enc.Transmit(&buf, 1, newTestImage(16, 4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Width != 8 || img.Height != 2 {
+		t.Errorf("got %dx%d, expected 8x2", img.Width, img.Height)
+	}
+
+	f, err := os.Open("testdata/go-logo-blue.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	buf.Reset()
+	enc.Fit = image.Pt(100, 0)
+	if img, err = enc.TransmitFile(&buf, 2, f); err != nil {
+		t.Fatal(err)
+	}
+	// 207x78 scaled to fit a width of 100
+	if img.Width != 100 || img.Height != 38 {
+		t.Errorf("got %dx%d, expected 100x38", img.Width, img.Height)
+	}
+	// PNG data must be decoded to be resized
+	params, pix := decodeImage(t, buf.Bytes())
+	if params['f'] != "32" || params['s'] != "100" || params['v'] != "38" || len(pix) != 100*38*4 {
+		t.Errorf("got %v with %d bytes of pixels", params, len(pix))
+	}
+}
+
+func TestTranscodeFitAnimated(t *testing.T) {
+	for _, file := range []string{"testdata/spinner.gif", "testdata/spinner.png"} {
+		t.Run(file, func(t *testing.T) {
+			f, err := os.Open(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			// 16x16 to 8x8
+			enc := kittyimg.Encoder{Fit: image.Pt(8, 100)}
+			var buf bytes.Buffer
+			if err = enc.Transcode(&buf, f); err != nil {
+				t.Fatal(err)
+			}
+
+			frames := 0
+			for bl := range extractBlocks(buf.Bytes()) {
+				t.Log(bl.Params)
+				if bl.Params['a'] != "T" && bl.Params['a'] != "f" {
+					continue
+				}
+				frames++
+				if bl.Params['f'] != "32" {
+					t.Errorf("got f=%s", bl.Params['f'])
+				}
+				atoi := func(k byte) int {
+					n, _ := strconv.Atoi(bl.Params[k])
+					return n
+				}
+				r := image.Rect(atoi('x'), atoi('y'), atoi('x')+atoi('s'), atoi('y')+atoi('v'))
+				if r.Empty() || !r.In(image.Rect(0, 0, 8, 8)) {
+					t.Errorf("frame %d: %v out of the 8x8 canvas", frames, r)
+				}
+				if frames == 1 && r.Size() != image.Pt(8, 8) {
+					t.Errorf("first frame: got %v", r.Size())
+				}
+				if len(inflate(t, bl)) != r.Dx()*r.Dy()*4 {
+					t.Errorf("frame %d: wrong payload size", frames)
+				}
+			}
+			if frames != 4 {
+				t.Errorf("got %d frames, expected 4", frames)
+			}
+		})
+	}
+}
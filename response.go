@@ -0,0 +1,234 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Errors reported by the terminal, matched by [ResponseError] with [errors.Is].
+//
+// See kitty's [graphics protocol] documentation.
+//
+// [graphics protocol]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#display-images-on-screen
+var (
+	ErrNoEntity = errors.New("kittyimg: no such image or placement")        // ENOENT
+	ErrInvalid  = errors.New("kittyimg: invalid command")                   // EINVAL
+	ErrBadPNG   = errors.New("kittyimg: invalid PNG data")                  // EBADPNG
+	ErrNoSpace  = errors.New("kittyimg: not enough storage space")          // ENOSPC
+	ErrTooBig   = errors.New("kittyimg: image too big")                     // EFBIG
+	ErrNoData   = errors.New("kittyimg: no image data")                     // ENODATA
+	ErrNoParent = errors.New("kittyimg: parent placement not found")        // ENOPARENT
+	ErrTooDeep  = errors.New("kittyimg: relative placement chain too deep") // ETOODEEP
+	ErrCycle    = errors.New("kittyimg: relative placement cycle")          // ECYCLE
+)
+
+var errorCodes = map[string]error{
+	"ENOENT":    ErrNoEntity,
+	"EINVAL":    ErrInvalid,
+	"EBADPNG":   ErrBadPNG,
+	"ENOSPC":    ErrNoSpace,
+	"EFBIG":     ErrTooBig,
+	"ENODATA":   ErrNoData,
+	"ENOPARENT": ErrNoParent,
+	"ETOODEEP":  ErrTooDeep,
+	"ECYCLE":    ErrCycle,
+}
+
+// Response is a reply from the terminal to a graphics command.
+//
+// The terminal replies only to commands that have an image ID (i=) or an image
+// number (I=). Commands sent by [Encoder] have q=1, so only errors are reported.
+type Response struct {
+	ID          uint32 // i=
+	Number      uint32 // I=
+	PlacementID uint32 // p=
+	Message     string // "OK" or the error
+}
+
+// Err returns nil if the response is a success, or a [*ResponseError].
+func (resp *Response) Err() error {
+	if resp.Message == "OK" {
+		return nil
+	}
+	e := &ResponseError{
+		ID:          resp.ID,
+		Number:      resp.Number,
+		PlacementID: resp.PlacementID,
+		Code:        resp.Message,
+	}
+	if i := strings.IndexByte(resp.Message, ':'); i >= 0 {
+		e.Code, e.Message = resp.Message[:i], resp.Message[i+1:]
+	}
+	return e
+}
+
+// ResponseError is an error reported by the terminal.
+type ResponseError struct {
+	ID          uint32 // i=
+	Number      uint32 // I=
+	PlacementID uint32 // p=
+	Code        string // ENOENT, EINVAL...
+	Message     string
+}
+
+func (e *ResponseError) Error() string {
+	b := []byte("kittyimg: ")
+	if e.ID != 0 {
+		b = append(b, "image "...)
+		b = strconv.AppendUint(b, uint64(e.ID), 10)
+		b = append(b, ": "...)
+	} else if e.Number != 0 {
+		b = append(b, "image number "...)
+		b = strconv.AppendUint(b, uint64(e.Number), 10)
+		b = append(b, ": "...)
+	}
+	b = append(b, e.Code...)
+	if e.Message != "" {
+		b = append(b, ": "...)
+		b = append(b, e.Message...)
+	}
+	return string(b)
+}
+
+// Unwrap returns the sentinel error matching the error code, such as [ErrNoEntity]
+// for ENOENT, or nil if the code is unknown.
+func (e *ResponseError) Unwrap() error {
+	return errorCodes[e.Code]
+}
+
+// ResponseParser reads [Response]s to graphics commands from a terminal.
+//
+// Any input which is not a graphics protocol response is skipped.
+type ResponseParser struct {
+	r *bufio.Reader
+}
+
+// NewResponseParser returns a parser of responses read from r, usually a
+// terminal in raw mode.
+func NewResponseParser(r io.Reader) *ResponseParser {
+	return &ResponseParser{r: bufio.NewReader(r)}
+}
+
+// Next returns the next response.
+func (p *ResponseParser) Next() (*Response, error) {
+	// Wait for "\033_G"
+	for state := 0; state < len(apcStart); {
+		c, err := p.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case c == apcStart[state]:
+			state++
+		case c == apcStart[0]:
+			state = 1
+		default:
+			state = 0
+		}
+	}
+
+	// Read up to "\033\\"
+	var buf []byte
+	for {
+		b, err := p.r.ReadSlice(apcEnd[0])
+		buf = append(buf, b...)
+		if err != nil {
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		c, err := p.r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if c == apcEnd[1] {
+			buf = buf[:len(buf)-1]
+			break
+		}
+		if c == apcEnd[0] {
+			// This ESC may start the string terminator
+			_ = p.r.UnreadByte()
+			continue
+		}
+		buf = append(buf, c)
+	}
+
+	return parseResponse(buf)
+}
+
+// ErrBadResponse is returned by [ResponseParser.Next] for malformed responses.
+var ErrBadResponse = errors.New("kittyimg: malformed response")
+
+func parseResponse(b []byte) (*Response, error) {
+	i := bytes.IndexByte(b, ';')
+	if i < 0 {
+		return nil, ErrBadResponse
+	}
+	var resp Response
+	resp.Message = string(b[i+1:])
+	for _, kv := range bytes.Split(b[:i], []byte{','}) {
+		if len(kv) < 3 || kv[1] != '=' {
+			return nil, ErrBadResponse
+		}
+		var dst *uint32
+		switch kv[0] {
+		case 'i':
+			dst = &resp.ID
+		case 'I':
+			dst = &resp.Number
+		case 'p':
+			dst = &resp.PlacementID
+		default:
+			continue
+		}
+		v, err := strconv.ParseUint(string(kv[2:]), 10, 32)
+		if err != nil {
+			return nil, ErrBadResponse
+		}
+		*dst = uint32(v)
+	}
+	return &resp, nil
+}
+
+// Wait returns the next response for the given image ID (if not 0) or image
+// number (if not 0), skipping responses related to other images.
+//
+// The returned error is the response error (see [Response.Err]) or a read error.
+func (p *ResponseParser) Wait(id, number uint32) (*Response, error) {
+	for {
+		resp, err := p.Next()
+		if err != nil {
+			return nil, err
+		}
+		if (id == 0 || resp.ID == id) && (number == 0 || resp.Number == number) {
+			return resp, resp.Err()
+		}
+	}
+}
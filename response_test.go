@@ -0,0 +1,128 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestResponseParser(t *testing.T) {
+	p := kittyimg.NewResponseParser(strings.NewReader("" +
+		"\033[?62;c" + // Unrelated reply
+		"\033_Gi=31;OK\033\\" +
+		"\033\033_Gi=32,p=4;ENOENT:Image not found\033\\" +
+		"\033_GI=7;EBADPNG:bad\033 data\033\\" +
+		"\033_Gi=33;EWHAT\033\\" +
+		"\033_Gi=34;OK",
+	))
+
+	resp, err := p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 31 || resp.Message != "OK" || resp.Err() != nil {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	resp, err = p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = resp.Err()
+	t.Log(err)
+	if !errors.Is(err, kittyimg.ErrNoEntity) {
+		t.Errorf("got %v, expected ErrNoEntity", err)
+	}
+	var rerr *kittyimg.ResponseError
+	if !errors.As(err, &rerr) || rerr.ID != 32 || rerr.PlacementID != 4 || rerr.Code != "ENOENT" || rerr.Message != "Image not found" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	_, err = p.Wait(0, 7)
+	t.Log(err)
+	if !errors.Is(err, kittyimg.ErrBadPNG) || !errors.As(err, &rerr) || rerr.Message != "bad\033 data" {
+		t.Errorf("got %v, expected ErrBadPNG", err)
+	}
+
+	resp, err = p.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = resp.Err()
+	if err == nil || errors.Unwrap(err) != nil || err.Error() != "kittyimg: image 33: EWHAT" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err = p.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, expected io.ErrUnexpectedEOF", err)
+	}
+	if _, err = p.Next(); err != io.EOF {
+		t.Errorf("got %v, expected io.EOF", err)
+	}
+}
+
+func TestResponseParserEscapeBeforeST(t *testing.T) {
+	p := kittyimg.NewResponseParser(strings.NewReader("" +
+		"\033_Gi=1;EINVAL:x\033\033\\" +
+		"\033_Gi=2;OK\033\\" +
+		"\033_Gi=3;EINVAL:y\033\033\033\\",
+	))
+	for _, expected := range []kittyimg.Response{
+		{ID: 1, Message: "EINVAL:x\033"},
+		{ID: 2, Message: "OK"},
+		{ID: 3, Message: "EINVAL:y\033\033"},
+	} {
+		resp, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *resp != expected {
+			t.Errorf("got %+v, expected %+v", *resp, expected)
+		}
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Errorf("got %v, expected io.EOF", err)
+	}
+}
+
+func TestResponseParserMalformed(t *testing.T) {
+	p := kittyimg.NewResponseParser(strings.NewReader("\033_Gi=x;OK\033\\\033_GOK\033\\"))
+	for i := 0; i < 2; i++ {
+		if _, err := p.Next(); !errors.Is(err, kittyimg.ErrBadResponse) {
+			t.Errorf("got %v, expected ErrBadResponse", err)
+		}
+	}
+}
+
+func TestResponseParserWait(t *testing.T) {
+	p := kittyimg.NewResponseParser(strings.NewReader("" +
+		"\033_Gi=1;ENOSPC\033\\" +
+		"\033_Gi=2;OK\033\\",
+	))
+	resp, err := p.Wait(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != 2 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,35 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// shmDir is where POSIX shared memory objects are mapped on Linux.
+const shmDir = "/dev/shm"
+
+// createSharedMemory creates a POSIX shared memory object, open for writing,
+// and returns it with its name.
+func createSharedMemory() (*os.File, string, error) {
+	f, err := os.CreateTemp(shmDir, tempFilePattern)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, "/" + filepath.Base(f.Name()), nil
+}
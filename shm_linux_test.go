@@ -0,0 +1,65 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func TestEncodeSharedMemory(t *testing.T) {
+	if _, err := os.Stat("/dev/shm"); err != nil {
+		t.Skip(err)
+	}
+
+	img := newAlphaImage()
+	enc := kittyimg.Encoder{Medium: kittyimg.MediumSharedMemory}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		if bl.Params['t'] != "s" || bl.Params['f'] != "32" || bl.Params['o'] != "" {
+			t.Errorf("unexpected params %s", bl.Params)
+		}
+		name := string(bl.Payload)
+		if !strings.HasPrefix(name, "/tty-graphics-protocol-") || strings.Contains(name[1:], "/") {
+			t.Fatalf("unexpected name %q", name)
+		}
+		// The terminal unlinks the object once read
+		data, err := os.ReadFile("/dev/shm" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Remove("/dev/shm" + name)
+		if !bytes.Equal(data, img.Pix) {
+			t.Errorf("got %x", data)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("got %d blocks", n)
+	}
+}
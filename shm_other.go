@@ -0,0 +1,33 @@
+//go:build !linux
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+var errSharedMemory = errors.New("kittyimg: shared memory medium not supported on " + runtime.GOOS)
+
+// createSharedMemory creates a POSIX shared memory object, open for writing,
+// and returns it with its name.
+func createSharedMemory() (*os.File, string, error) {
+	return nil, "", errSharedMemory
+}
@@ -0,0 +1,356 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// SixelEncoder encodes images as [sixel] graphics, for terminals that
+// support sixel but not kitty's graphics protocol (xterm, foot, mlterm...).
+//
+// Images are quantised to a palette built with the median cut algorithm.
+// Pixels with an alpha below 50% are left transparent (P2=1).
+//
+// [sixel]: https://vt100.net/docs/vt3xx-gp/chapter14.html
+type SixelEncoder struct {
+	// Colors is the maximum number of colors of the palette, from 2 to 256.
+	// The default, 0, is 256.
+	Colors int
+
+	// Dither enables Floyd–Steinberg error diffusion, which hides the
+	// banding of gradients quantised to few colors.
+	Dither bool
+
+	// Fit and ScaleUp resize images like [Encoder.Fit] and [Encoder.ScaleUp].
+	Fit     image.Point
+	ScaleUp bool
+
+	buf []byte
+}
+
+// sixelMaxColors is the maximum size of the palette (the number of color
+// registers of most terminals).
+const sixelMaxColors = 256
+
+// Encode writes img as a sixel image at the cursor position.
+func (enc *SixelEncoder) Encode(w io.Writer, img image.Image) error {
+	img = resize(img, enc.Fit, enc.ScaleUp)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Straight RGBA pixels
+	pix := make([]byte, 0, 4*width*height)
+	appendRGBA := rgbaAppender(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		pix = appendRGBA(pix, bounds.Min.X, y, width)
+	}
+
+	colors := enc.Colors
+	if colors <= 0 || colors > sixelMaxColors {
+		colors = sixelMaxColors
+	}
+	palette := medianCut(colorHistogram(pix), max(colors, 2))
+	indices := quantise(pix, width, palette, enc.Dither)
+
+	b := append(enc.buf[:0], "\033P0;1;0q\"1;1;"...)
+	b = strconv.AppendInt(b, int64(width), 10)
+	b = append(b, ';')
+	b = strconv.AppendInt(b, int64(height), 10)
+	for i, c := range palette {
+		b = append(b, '#')
+		b = strconv.AppendInt(b, int64(i), 10)
+		b = append(b, ";2"...)
+		for _, v := range c {
+			b = append(b, ';')
+			b = strconv.AppendInt(b, int64((int(v)*100+127)/255), 10)
+		}
+	}
+	b = appendSixels(b, indices, width, len(palette))
+	b = append(b, "\033\\"...)
+	enc.buf = b
+
+	_, err := w.Write(b)
+	return err
+}
+
+// colorCount is an entry of a color histogram.
+type colorCount struct {
+	rgb [3]uint8
+	n   int
+}
+
+// colorHistogram returns the histogram of the opaque colors of the straight
+// RGBA pixels pix.
+func colorHistogram(pix []byte) []colorCount {
+	counts := make(map[[3]uint8]int)
+	for i := 0; i < len(pix); i += 4 {
+		if pix[i+3] >= 0x80 {
+			counts[[3]uint8{pix[i], pix[i+1], pix[i+2]}]++
+		}
+	}
+	hist := make([]colorCount, 0, len(counts))
+	for rgb, n := range counts {
+		hist = append(hist, colorCount{rgb, n})
+	}
+	// Deterministic output
+	slices.SortFunc(hist, func(a, b colorCount) int {
+		return slices.Compare(a.rgb[:], b.rgb[:])
+	})
+	return hist
+}
+
+// colorBox is a set of colors of a histogram, with the channel that has the
+// widest range.
+type colorBox struct {
+	colors  []colorCount
+	channel int
+	width   int
+}
+
+func newColorBox(colors []colorCount) colorBox {
+	box := colorBox{colors: colors}
+	for c := 0; c < 3; c++ {
+		lo, hi := colors[0].rgb[c], colors[0].rgb[c]
+		for _, cc := range colors[1:] {
+			lo, hi = min(lo, cc.rgb[c]), max(hi, cc.rgb[c])
+		}
+		if int(hi-lo) > box.width {
+			box.channel, box.width = c, int(hi-lo)
+		}
+	}
+	return box
+}
+
+// medianCut returns a palette of at most n colors for the histogram hist.
+// The box of colors with the widest range on a channel is split at the
+// median of that channel until n boxes are built. Each box gives the
+// average of its colors.
+func medianCut(hist []colorCount, n int) [][3]uint8 {
+	if len(hist) <= n {
+		palette := make([][3]uint8, len(hist))
+		for i, c := range hist {
+			palette[i] = c.rgb
+		}
+		return palette
+	}
+
+	boxes := []colorBox{newColorBox(hist)}
+	for len(boxes) < n {
+		// Select the box with the widest range
+		bi := 0
+		for i := range boxes {
+			if boxes[i].width > boxes[bi].width {
+				bi = i
+			}
+		}
+		if boxes[bi].width == 0 {
+			break
+		}
+
+		box, ch := boxes[bi].colors, boxes[bi].channel
+		slices.SortStableFunc(box, func(a, b colorCount) int {
+			return int(a.rgb[ch]) - int(b.rgb[ch])
+		})
+		total := 0
+		for _, cc := range box {
+			total += cc.n
+		}
+		// Split at the median, keeping at least one color on each side
+		k, acc := 1, box[0].n
+		for k < len(box)-1 && 2*acc < total {
+			acc += box[k].n
+			k++
+		}
+		boxes[bi] = newColorBox(box[:k])
+		boxes = append(boxes, newColorBox(box[k:]))
+	}
+
+	palette := make([][3]uint8, len(boxes))
+	for i, box := range boxes {
+		var sum [3]int
+		total := 0
+		for _, cc := range box.colors {
+			for c := range sum {
+				sum[c] += int(cc.rgb[c]) * cc.n
+			}
+			total += cc.n
+		}
+		for c := range sum {
+			palette[i][c] = uint8((sum[c] + total/2) / total)
+		}
+	}
+	return palette
+}
+
+// nearest returns the index of the color of palette closest to rgb.
+func nearest(palette [][3]uint8, r, g, b int) int {
+	best, bestDist := 0, -1
+	for i, c := range palette {
+		dr, dg, db := r-int(c[0]), g-int(c[1]), b-int(c[2])
+		if d := dr*dr + dg*dg + db*db; bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+			if d == 0 {
+				break
+			}
+		}
+	}
+	return best
+}
+
+// quantise maps the straight RGBA pixels pix of an image of the given width
+// to palette indices, or -1 for transparent pixels. With dither, the
+// quantisation error is diffused to the neighbour pixels (Floyd–Steinberg).
+func quantise(pix []byte, width int, palette [][3]uint8, dither bool) []int16 {
+	indices := make([]int16, len(pix)/4)
+	if len(palette) == 0 {
+		for i := range indices {
+			indices[i] = -1
+		}
+		return indices
+	}
+
+	cache := make(map[[3]uint8]int16)
+	index := func(r, g, b int) int16 {
+		key := [3]uint8{clamp8(r), clamp8(g), clamp8(b)}
+		i, ok := cache[key]
+		if !ok {
+			i = int16(nearest(palette, int(key[0]), int(key[1]), int(key[2])))
+			cache[key] = i
+		}
+		return i
+	}
+
+	// Errors (in 1/16) of the current and next rows, with a margin of one
+	// pixel on each side
+	var errCur, errNext [][3]int
+	if dither {
+		errCur, errNext = make([][3]int, width+2), make([][3]int, width+2)
+	}
+	for y := 0; y < len(indices)/max(width, 1); y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			p := pix[4*i : 4*i+4]
+			if p[3] < 0x80 {
+				indices[i] = -1
+				continue
+			}
+			if !dither {
+				indices[i] = index(int(p[0]), int(p[1]), int(p[2]))
+				continue
+			}
+			e := errCur[x+1]
+			r, g, b := int(p[0])+e[0]/16, int(p[1])+e[1]/16, int(p[2])+e[2]/16
+			indices[i] = index(r, g, b)
+			c := palette[indices[i]]
+			qe := [3]int{clamp(r) - int(c[0]), clamp(g) - int(c[1]), clamp(b) - int(c[2])}
+			for ch, v := range qe {
+				errCur[x+2][ch] += v * 7
+				errNext[x][ch] += v * 3
+				errNext[x+1][ch] += v * 5
+				errNext[x+2][ch] += v
+			}
+		}
+		if dither {
+			errCur, errNext = errNext, errCur
+			clear(errNext)
+		}
+	}
+	return indices
+}
+
+func clamp(v int) int {
+	return min(max(v, 0), 0xff)
+}
+
+func clamp8(v int) uint8 {
+	return uint8(clamp(v))
+}
+
+// appendSixels appends the sixel data of the image of palette indices.
+// Each band of 6 rows is drawn color by color (#n), going back to the start
+// of the band ($) between colors, and to the next band (-) at the end.
+// Repeated sixels are compressed (!count).
+func appendSixels(b []byte, indices []int16, width, colors int) []byte {
+	height := len(indices) / max(width, 1)
+	line := make([]byte, width)
+	used := make([]bool, colors)
+	for y0 := 0; y0 < height; y0 += 6 {
+		if y0 > 0 {
+			b = append(b, '-')
+		}
+		rows := min(6, height-y0)
+		clear(used)
+		for _, i := range indices[y0*width : (y0+rows)*width] {
+			if i >= 0 {
+				used[i] = true
+			}
+		}
+		first := true
+		for color, ok := range used {
+			if !ok {
+				continue
+			}
+			end := 0
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < rows; dy++ {
+					if indices[(y0+dy)*width+x] == int16(color) {
+						bits |= 1 << dy
+					}
+				}
+				line[x] = '?' + bits
+				if bits != 0 {
+					end = x + 1
+				}
+			}
+			if !first {
+				b = append(b, '$')
+			}
+			first = false
+			b = append(b, '#')
+			b = strconv.AppendInt(b, int64(color), 10)
+			b = appendRLE(b, line[:end])
+		}
+	}
+	return b
+}
+
+// appendRLE appends the sixels of line, with runs of more than 3 identical
+// sixels compressed.
+func appendRLE(b []byte, line []byte) []byte {
+	for i := 0; i < len(line); {
+		n := 1
+		for i+n < len(line) && line[i+n] == line[i] {
+			n++
+		}
+		if n > 3 {
+			b = append(b, '!')
+			b = strconv.AppendInt(b, int64(n), 10)
+			b = append(b, line[i])
+		} else {
+			for j := 0; j < n; j++ {
+				b = append(b, line[i])
+			}
+		}
+		i += n
+	}
+	return b
+}
@@ -0,0 +1,260 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// decodeSixel decodes a sixel image written by SixelEncoder into a palette
+// image. The index 0 is transparent and the color register n has the index n+1.
+func decodeSixel(t *testing.T, s string) *image.Paletted {
+	t.Helper()
+	header, ok := strings.CutPrefix(s, "\033P0;1;0q")
+	if !ok {
+		t.Fatalf("invalid header: %q", s[:min(len(s), 20)])
+	}
+	s, ok = strings.CutSuffix(header, "\033\\")
+	if !ok {
+		t.Fatal("missing string terminator")
+	}
+
+	// number parses a decimal number at the start of s
+	number := func() int {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			t.Fatalf("number expected at %q", s[:min(len(s), 20)])
+		}
+		s = s[i:]
+		return n
+	}
+	expect := func(c byte) {
+		if len(s) == 0 || s[0] != c {
+			t.Fatalf("%q expected at %q", c, s[:min(len(s), 20)])
+		}
+		s = s[1:]
+	}
+
+	expect('"')
+	expect('1')
+	expect(';')
+	expect('1')
+	expect(';')
+	w := number()
+	expect(';')
+	h := number()
+
+	pal := color.Palette{color.Transparent}
+	img := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+	x, y, reg := 0, 0, 0
+	for len(s) > 0 {
+		c := s[0]
+		switch {
+		case c == '#':
+			s = s[1:]
+			reg = number()
+			if len(s) > 0 && s[0] == ';' {
+				s = s[1:]
+				expect('2')
+				var rgb [3]uint8
+				for i := range rgb {
+					expect(';')
+					rgb[i] = uint8((number()*255 + 50) / 100)
+				}
+				if reg+1 != len(pal) {
+					t.Fatalf("color register %d defined out of order", reg)
+				}
+				pal = append(pal, color.NRGBA{rgb[0], rgb[1], rgb[2], 0xff})
+			} else if reg+1 >= len(pal) {
+				t.Fatalf("undefined color register %d", reg)
+			}
+		case c == '$':
+			s = s[1:]
+			x = 0
+		case c == '-':
+			s = s[1:]
+			x, y = 0, y+6
+		case c == '!' || (c >= '?' && c <= '~'):
+			n := 1
+			if c == '!' {
+				s = s[1:]
+				n = number()
+				if len(s) == 0 {
+					t.Fatal("truncated repeat")
+				}
+				c = s[0]
+			}
+			s = s[1:]
+			bits := c - '?'
+			for ; n > 0; n-- {
+				for dy := 0; dy < 6; dy++ {
+					if bits&(1<<dy) != 0 {
+						if x >= w || y+dy >= h {
+							t.Fatalf("sixel out of the image at (%d, %d)", x, y+dy)
+						}
+						img.SetColorIndex(x, y+dy, uint8(reg+1))
+					}
+				}
+				x++
+			}
+		default:
+			t.Fatalf("unexpected %q", s[:min(len(s), 20)])
+		}
+	}
+	img.Palette = pal
+	return img
+}
+
+// percent applies the loss of precision of sixel color registers.
+func percent(v uint8) uint8 {
+	return uint8(((int(v)*100+127)/255*255 + 50) / 100)
+}
+
+func TestSixelEncode(t *testing.T) {
+	colors := []color.NRGBA{
+		{0xff, 0x00, 0x00, 0xff},
+		{0x00, 0xad, 0xd8, 0xff},
+		{0xfd, 0xdd, 0x00, 0x90},
+		{0x10, 0x20, 0x30, 0xff},
+		{0xff, 0xff, 0xff, 0x7f}, // transparent
+	}
+	// Height is not a multiple of 6
+	src := image.NewNRGBA(image.Rect(2, 3, 2+21, 3+13))
+	for y := 0; y < 13; y++ {
+		for x := 0; x < 21; x++ {
+			src.SetNRGBA(2+x, 3+y, colors[(x/4+y/3)%len(colors)])
+		}
+	}
+
+	var enc kittyimg.SixelEncoder
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%q", buf.String())
+	img := decodeSixel(t, buf.String())
+	if img.Rect != image.Rect(0, 0, 21, 13) {
+		t.Fatalf("got bounds %v", img.Rect)
+	}
+	if len(img.Palette) != 5 {
+		t.Errorf("got %d colors, expected 4", len(img.Palette)-1)
+	}
+	for y := 0; y < 13; y++ {
+		for x := 0; x < 21; x++ {
+			c := colors[(x/4+y/3)%len(colors)]
+			expected := color.NRGBA{percent(c.R), percent(c.G), percent(c.B), 0xff}
+			if c.A < 0x80 {
+				expected = color.NRGBA{}
+			}
+			if got := color.NRGBAModel.Convert(img.At(x, y)); got != expected {
+				t.Fatalf("pixel (%d, %d): got %v, expected %v", x, y, got, expected)
+			}
+		}
+	}
+}
+
+func TestSixelEncodeQuantise(t *testing.T) {
+	src := newTestImage(64, 48)
+	var sum [3]int
+	for i := 0; i < len(src.Pix); i += 4 {
+		for c := range sum {
+			sum[c] += int(src.Pix[i+c])
+		}
+	}
+
+	for _, dither := range []bool{false, true} {
+		t.Run("dither="+strconv.FormatBool(dither), func(t *testing.T) {
+			enc := kittyimg.SixelEncoder{Colors: 16, Dither: dither}
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, src); err != nil {
+				t.Fatal(err)
+			}
+			img := decodeSixel(t, buf.String())
+			if n := len(img.Palette) - 1; n != 16 {
+				t.Errorf("got %d colors, expected 16", n)
+			}
+
+			var got [3]int
+			var errSum int
+			for y := 0; y < 48; y++ {
+				for x := 0; x < 64; x++ {
+					c := img.Palette[img.ColorIndexAt(x, y)].(color.NRGBA)
+					if c.A == 0 {
+						t.Fatalf("pixel (%d, %d) is transparent", x, y)
+					}
+					s := src.NRGBAAt(x, y)
+					ref := [3]int{int(s.R), int(s.G), int(s.B)}
+					for ch, v := range [3]int{int(c.R), int(c.G), int(c.B)} {
+						got[ch] += v
+						errSum += max(v-ref[ch], ref[ch]-v)
+					}
+				}
+			}
+			// Mean error per channel
+			e := errSum / (64 * 48 * 3)
+			t.Logf("mean error: %d", e)
+			if e > 16 {
+				t.Errorf("mean error %d", e)
+			}
+			// Dithering preserves the average color
+			for ch := range got {
+				if d := (got[ch] - sum[ch]) / (64 * 48); dither && (d < -2 || d > 2) {
+					t.Errorf("channel %d: average differs by %d", ch, d)
+				}
+			}
+		})
+	}
+}
+
+func TestSixelEncodeRLE(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 100, 7))
+	for i := 0; i < len(src.Pix); i += 4 {
+		copy(src.Pix[i:], []uint8{0x00, 0x80, 0xff, 0xff})
+	}
+	var enc kittyimg.SixelEncoder
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	if got, expected := buf.String(), "\033P0;1;0q\"1;1;100;7#0;2;0;50;100#0!100~-#0!100@\033\\"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestSixelEncodeFit(t *testing.T) {
+	enc := kittyimg.SixelEncoder{Fit: image.Pt(50, 0)}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, newTestImage(200, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if img := decodeSixel(t, buf.String()); img.Rect.Size() != image.Pt(50, 25) {
+		t.Errorf("got size %v", img.Rect.Size())
+	}
+}
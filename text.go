@@ -0,0 +1,334 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"image"
+	"io"
+	"strconv"
+)
+
+// TextColors is the color palette used by [TextEncoder].
+type TextColors int
+
+const (
+	// TextTrueColor uses 24-bit colors (SGR 38;2 and 48;2).
+	TextTrueColor TextColors = iota
+	// Text256Colors uses the 256 colors palette of xterm (SGR 38;5 and 48;5).
+	Text256Colors
+	// Text16Colors uses the 16 ANSI colors (SGR 30-37, 90-97, 40-47, 100-107),
+	// with the default palette of xterm.
+	Text16Colors
+)
+
+// TextBlocks is the kind of block characters used by [TextEncoder].
+type TextBlocks int
+
+const (
+	// TextHalfBlocks renders 1×2 pixels per cell with the upper and lower
+	// half block characters (▀ and ▄).
+	TextHalfBlocks TextBlocks = iota
+	// TextQuadrants renders 2×2 pixels per cell with the quadrant
+	// characters (▘, ▚, ▟...), in two colors per cell.
+	TextQuadrants
+)
+
+// TextEncoder renders images as text, with Unicode block characters colored
+// with SGR escape codes, for terminals without graphics support (Linux
+// console, CI logs...).
+//
+// Cells are assumed to be twice as high as wide. Like with [SixelEncoder],
+// pixels with an alpha below 50% are left transparent: they show the
+// default background color. Other translucent pixels are alpha blended over
+// the color of the rest of the cell, or over black if the rest of the cell
+// is transparent, as the background color of the terminal is not known.
+type TextEncoder struct {
+	// Colors is the color palette. The default is TextTrueColor.
+	Colors TextColors
+
+	// Blocks is the kind of block characters. The default is TextHalfBlocks.
+	Blocks TextBlocks
+
+	// Fit, if not zero, is the size in cells (columns and rows) of the box
+	// in which images must fit. Larger images are scaled down like with
+	// [Encoder.Fit]. A zero width or height is not constrained.
+	Fit image.Point
+
+	// ScaleUp enlarges images smaller than Fit to fill it.
+	ScaleUp bool
+
+	buf []byte
+}
+
+// Glyphs of cells indexed by the mask of foreground pixels: bit y*width+x
+// is set for the pixel (x, y) of the cell.
+var (
+	halfBlocks = [...]string{" ", "▀", "▄", "█"}
+	quadrants  = [...]string{
+		" ", "▘", "▝", "▀", "▖", "▌", "▞", "▛",
+		"▗", "▚", "▐", "▜", "▄", "▙", "▟", "█",
+	}
+)
+
+// ansiColors is the default palette of xterm for the 16 ANSI colors.
+var ansiColors = [][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// Encode writes img as lines of text at the cursor position. The cursor is
+// left at the end of the last line.
+func (enc *TextEncoder) Encode(w io.Writer, img image.Image) error {
+	cell, glyphs := image.Pt(1, 2), halfBlocks[:]
+	if enc.Blocks == TextQuadrants {
+		cell, glyphs = image.Pt(2, 2), quadrants[:]
+	}
+	img = enc.resize(img, cell)
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// Straight RGBA pixels
+	pix := make([]byte, 0, 4*width*height)
+	appendRGBA := rgbaAppender(img)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		pix = appendRGBA(pix, bounds.Min.X, y, width)
+	}
+
+	b := enc.buf[:0]
+	var (
+		px     [][4]byte
+		fg, bg string // current SGR colors ("" is the default)
+	)
+	rows, cols := (height+cell.Y-1)/cell.Y, (width+cell.X-1)/cell.X
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			px = px[:0]
+			for dy := 0; dy < cell.Y; dy++ {
+				for dx := 0; dx < cell.X; dx++ {
+					x, y := col*cell.X+dx, row*cell.Y+dy
+					var p [4]byte // Transparent outside the image
+					if x < width && y < height {
+						i := 4 * (y*width + x)
+						p = [4]byte(pix[i : i+4])
+					}
+					px = append(px, p)
+				}
+			}
+
+			mask, fgColor, bgColor, opaque := splitCell(px)
+			newFG, newBG := fg, ""
+			if mask != 0 {
+				newFG = string(enc.Colors.appendSGR(nil, fgColor, false))
+			}
+			if opaque {
+				newBG = string(enc.Colors.appendSGR(nil, bgColor, true))
+			}
+			if newFG != fg || newBG != bg {
+				b = append(b, "\033["...)
+				sep := false
+				if newFG != fg {
+					b = append(b, newFG...)
+					sep = true
+				}
+				if newBG != bg {
+					if sep {
+						b = append(b, ';')
+					}
+					if newBG == "" {
+						b = append(b, "49"...)
+					} else {
+						b = append(b, newBG...)
+					}
+				}
+				b = append(b, 'm')
+				fg, bg = newFG, newBG
+			}
+			b = append(b, glyphs[mask]...)
+		}
+		// Reset colors before the end of the line, as the terminal fills
+		// new lines with the current background color when scrolling
+		if fg != "" || bg != "" {
+			b = append(b, "\033[m"...)
+			fg, bg = "", ""
+		}
+		if row < rows-1 {
+			b = append(b, '\n')
+		}
+	}
+	enc.buf = b
+
+	_, err := w.Write(b)
+	return err
+}
+
+// resize returns img resized to fit in [TextEncoder.Fit] with cells of
+// cell pixels, compensating the aspect ratio of pixels.
+func (enc *TextEncoder) resize(img image.Image, cell image.Point) image.Image {
+	// In square units of a cell.X-th of the cell width, a cell is
+	// cell.X × 2*cell.X, so each pixel is 2*cell.X/cell.Y units high
+	bounds := img.Bounds()
+	size := fitSize(bounds.Size(), image.Pt(enc.Fit.X*cell.X, enc.Fit.Y*2*cell.X), enc.ScaleUp)
+	size.Y = max(1, (size.Y*cell.Y+cell.X)/(2*cell.X))
+	if size == bounds.Size() {
+		return img
+	}
+	return scale(img, size)
+}
+
+// splitCell splits the straight RGBA pixels px of a cell into foreground and
+// background. mask has the bits of foreground pixels, and opaque reports
+// whether the background is opaque (otherwise it is the default background
+// color of the terminal).
+func splitCell(px [][4]byte) (mask int, fg, bg [3]uint8, opaque bool) {
+	transparent := false
+	for i, p := range px {
+		if p[3] >= 0x80 {
+			mask |= 1 << i
+		} else {
+			transparent = true
+		}
+	}
+	if mask == 0 {
+		return 0, fg, bg, false
+	}
+	black := &[3]uint8{}
+	if transparent {
+		return mask, meanColor(px, mask, black), bg, false
+	}
+
+	// Split the pixels around the two most distant colors
+	a, b, dist := 0, 0, 0
+	for i := range px {
+		for j := i + 1; j < len(px); j++ {
+			if d := colorDist(px[i], px[j]); d > dist {
+				a, b, dist = i, j, d
+			}
+		}
+	}
+	if dist == 0 {
+		return mask, meanColor(px, mask, black), bg, false
+	}
+	mask = 0
+	for i, p := range px {
+		if colorDist(p, px[a]) <= colorDist(p, px[b]) {
+			mask |= 1 << i
+		}
+	}
+	// Blend each part over the other one
+	all := 1<<len(px) - 1
+	fgStraight, bgStraight := meanColor(px, mask, nil), meanColor(px, all&^mask, nil)
+	return mask, meanColor(px, mask, &bgStraight), meanColor(px, all&^mask, &fgStraight), true
+}
+
+// colorDist returns the squared distance between the colors of two pixels.
+func colorDist(p, q [4]byte) int {
+	dr, dg, db := int(p[0])-int(q[0]), int(p[1])-int(q[1]), int(p[2])-int(q[2])
+	return dr*dr + dg*dg + db*db
+}
+
+// meanColor returns the mean color of the pixels of px selected by mask,
+// alpha blended over the color under, or ignoring alpha if under is nil.
+func meanColor(px [][4]byte, mask int, under *[3]uint8) (c [3]uint8) {
+	var sum [3]int
+	n := 0
+	for i, p := range px {
+		if mask&(1<<i) != 0 {
+			for ch := range sum {
+				v := int(p[ch])
+				if under != nil {
+					a := int(p[3])
+					v = (v*a + int(under[ch])*(0xff-a) + 0x7f) / 0xff
+				}
+				sum[ch] += v
+			}
+			n++
+		}
+	}
+	for ch := range sum {
+		c[ch] = uint8((sum[ch] + n/2) / n)
+	}
+	return c
+}
+
+// appendSGR appends the SGR parameters that set the foreground (or the
+// background, if bg) to the color c.
+func (colors TextColors) appendSGR(b []byte, c [3]uint8, bg bool) []byte {
+	switch colors {
+	case Text256Colors:
+		if bg {
+			b = append(b, "48;5;"...)
+		} else {
+			b = append(b, "38;5;"...)
+		}
+		return strconv.AppendInt(b, int64(xterm256(c)), 10)
+	case Text16Colors:
+		i := nearest(ansiColors, int(c[0]), int(c[1]), int(c[2]))
+		code := 30 + i
+		if i >= 8 {
+			code = 90 + i - 8
+		}
+		if bg {
+			code += 10
+		}
+		return strconv.AppendInt(b, int64(code), 10)
+	default:
+		if bg {
+			b = append(b, "48;2"...)
+		} else {
+			b = append(b, "38;2"...)
+		}
+		for _, v := range c {
+			b = append(b, ';')
+			b = strconv.AppendInt(b, int64(v), 10)
+		}
+		return b
+	}
+}
+
+// xterm256 returns the index of the color of the 256 colors palette of xterm
+// closest to c, from the 6×6×6 color cube (16-231) or the gray ramp (232-255).
+func xterm256(c [3]uint8) int {
+	level := func(v uint8) int {
+		switch {
+		case v < 48:
+			return 0
+		case v < 115:
+			return 1
+		default:
+			return (int(v) - 35) / 40
+		}
+	}
+	value := func(l int) uint8 {
+		if l == 0 {
+			return 0
+		}
+		return uint8(55 + 40*l)
+	}
+	r, g, b := level(c[0]), level(c[1]), level(c[2])
+	cube := [4]byte{value(r), value(g), value(b)}
+
+	gray := min(max((int(c[0])+int(c[1])+int(c[2]))/3-3, 0)/10, 23)
+	v := uint8(8 + 10*gray)
+
+	p := [4]byte{c[0], c[1], c[2]}
+	if colorDist(p, [4]byte{v, v, v}) < colorDist(p, cube) {
+		return 232 + gray
+	}
+	return 16 + 36*r + 6*g + b
+}
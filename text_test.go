@@ -0,0 +1,134 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+// newPixels returns an image of the given width with the colors of pix.
+func newPixels(width int, pix ...color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, len(pix)/width))
+	for i, c := range pix {
+		img.SetNRGBA(i%width, i/width, c)
+	}
+	return img
+}
+
+func TestTextEncode(t *testing.T) {
+	var (
+		red         = color.NRGBA{255, 0, 0, 255}
+		green       = color.NRGBA{0, 255, 0, 255}
+		blue        = color.NRGBA{0, 0, 255, 255}
+		transparent = color.NRGBA{}
+	)
+	for _, tc := range []struct {
+		name     string
+		enc      kittyimg.TextEncoder
+		img      image.Image
+		expected string
+	}{
+		{"two-colors", kittyimg.TextEncoder{}, newPixels(1, red, blue),
+			"\033[38;2;255;0;0;48;2;0;0;255m▀\033[m"},
+		{"transparent", kittyimg.TextEncoder{}, newPixels(2, red, transparent, red, green),
+			"\033[38;2;255;0;0m█\033[38;2;0;255;0m▄\033[m"},
+		{"lines", kittyimg.TextEncoder{}, newPixels(1, red, red, red),
+			"\033[38;2;255;0;0m█\033[m\n\033[38;2;255;0;0m▀\033[m"},
+		{"empty", kittyimg.TextEncoder{}, newPixels(2, transparent, transparent, transparent, transparent),
+			"  "},
+		{"256", kittyimg.TextEncoder{Colors: kittyimg.Text256Colors}, newPixels(1, red, blue),
+			"\033[38;5;196;48;5;21m▀\033[m"},
+		{"16", kittyimg.TextEncoder{Colors: kittyimg.Text16Colors}, newPixels(1, red, blue),
+			"\033[91;44m▀\033[m"},
+		{"background", kittyimg.TextEncoder{}, newPixels(2, red, transparent, blue, red),
+			"\033[38;2;255;0;0;48;2;0;0;255m▀\033[49m▄\033[m"},
+		{"translucent", kittyimg.TextEncoder{}, newPixels(1, color.NRGBA{255, 0, 0, 0x80}, transparent),
+			"\033[38;2;128;0;0m▀\033[m"},
+		{"translucent-over", kittyimg.TextEncoder{}, newPixels(1, color.NRGBA{255, 0, 0, 0x80}, blue),
+			"\033[38;2;128;0;127;48;2;0;0;255m▀\033[m"},
+		{"below-half", kittyimg.TextEncoder{}, newPixels(1, color.NRGBA{255, 0, 0, 0x7f}, blue),
+			"\033[38;2;0;0;255m▄\033[m"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.enc.Encode(&buf, tc.img); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tc.expected {
+				t.Errorf("got %q, expected %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestTextEncodeQuadrants(t *testing.T) {
+	red := color.NRGBA{255, 0, 0, 255}
+	blue := color.NRGBA{0, 0, 255, 255}
+	// Pixels of quadrants are twice as high as wide
+	img := newPixels(2, red, blue, red, blue, blue, red, blue, red)
+	enc := kittyimg.TextEncoder{Blocks: kittyimg.TextQuadrants}
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if got := sgrRE.ReplaceAllString(buf.String(), ""); got != "▚" {
+		t.Errorf("got %q, expected %q", got, "▚")
+	}
+}
+
+var sgrRE = regexp.MustCompile("\033\\[[0-9;]*m")
+
+func TestTextEncodeFit(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		enc      kittyimg.TextEncoder
+		size     image.Point
+		expected image.Point // in cells
+	}{
+		{"half", kittyimg.TextEncoder{Fit: image.Pt(10, 0)}, image.Pt(100, 100), image.Pt(10, 5)},
+		{"half-height", kittyimg.TextEncoder{Fit: image.Pt(80, 5)}, image.Pt(100, 100), image.Pt(10, 5)},
+		{"quadrants", kittyimg.TextEncoder{Fit: image.Pt(10, 0), Blocks: kittyimg.TextQuadrants}, image.Pt(100, 100), image.Pt(10, 5)},
+		{"small", kittyimg.TextEncoder{Fit: image.Pt(10, 10)}, image.Pt(4, 4), image.Pt(4, 2)},
+		{"scale-up", kittyimg.TextEncoder{Fit: image.Pt(8, 10), ScaleUp: true}, image.Pt(4, 4), image.Pt(8, 4)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tc.enc.Encode(&buf, newTestImage(tc.size.X, tc.size.Y)); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(sgrRE.ReplaceAllString(buf.String(), ""), "\n")
+			if len(lines) != tc.expected.Y {
+				t.Fatalf("got %d lines, expected %d", len(lines), tc.expected.Y)
+			}
+			for i, line := range lines {
+				if n := utf8.RuneCountInString(line); n != tc.expected.X {
+					t.Errorf("line %d: got %d cells, expected %d", i, n, tc.expected.X)
+				}
+			}
+		})
+	}
+}
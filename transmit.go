@@ -0,0 +1,90 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// ErrInvalidID is returned when an image ID of 0 is given to [Encoder.Transmit],
+// or by [Encoder.Encode] and [Encoder.Transcode] for a virtual placement, which
+// requires an image ID.
+var ErrInvalidID = errors.New("kittyimg: image ID must not be 0")
+
+// Image is a handle to an image stored by the terminal, transmitted once with
+// [Encoder.Transmit] or [Encoder.TransmitFile], which can then be displayed
+// many times with [Image.Place] without sending the pixels again.
+//
+// See [image ids].
+//
+// [image ids]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#display-images-on-screen
+type Image struct {
+	ID     uint32 // i=
+	Width  int    // in pixels
+	Height int    // in pixels
+}
+
+// Transmit [transmits] img to the terminal with the image ID id (a=t,i=id),
+// without displaying it.
+//
+// The image is stored by the terminal under that ID, replacing any previous
+// image with the same ID.
+//
+// [transmits]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#transferring-pixel-data
+func (enc *Encoder) Transmit(w io.Writer, id uint32, img image.Image) (*Image, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	if err := enc.encode(w, img, 't', id); err != nil {
+		return nil, err
+	}
+	size := enc.fitSize(img.Bounds().Size())
+	return &Image{ID: id, Width: size.X, Height: size.Y}, nil
+}
+
+// TransmitFile is like [Encoder.Transmit], but reads the image file from r like
+// [Encoder.Transcode].
+func (enc *Encoder) TransmitFile(w io.Writer, id uint32, r io.Reader) (*Image, error) {
+	if id == 0 {
+		return nil, ErrInvalidID
+	}
+	cfg, err := enc.transcode(w, r, 't', id)
+	if err != nil {
+		return nil, err
+	}
+	return &Image{ID: id, Width: cfg.Width, Height: cfg.Height}, nil
+}
+
+// Place [displays] the image at the cursor position (a=p), or at the cell
+// p.At.
+//
+// p controls the layout of the placement. It may be nil.
+//
+// [displays]: https://sw.kovidgoyal.net/kitty/graphics-protocol/#display-images-on-screen
+func (img *Image) Place(w io.Writer, p *Placement) error {
+	if err := p.check(img.ID); err != nil {
+		return err
+	}
+	cmd := appendCommand(make([]byte, 0, 64), 'p', img.ID)
+	cmd = appendPlacement(cmd, p)
+	return displayAt(w, p, func() error {
+		_, err := w.Write(append(cmd, ";"+apcEnd...))
+		return err
+	})
+}
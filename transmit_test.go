@@ -0,0 +1,116 @@
+//go:build go1.24
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func newTestImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 0x80, A: 0xff})
+		}
+	}
+	return img
+}
+
+func TestTransmitPlace(t *testing.T) {
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+
+	img, err := enc.Transmit(&buf, 42, newTestImage(16, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ID != 42 || img.Width != 16 || img.Height != 8 {
+		t.Fatalf("unexpected handle: %+v", img)
+	}
+	for range 3 {
+		if err := img.Place(&buf, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var blocks []*Block
+	for bl := range extractBlocks(buf.Bytes()) {
+		t.Log(bl.Params)
+		blocks = append(blocks, bl)
+	}
+	if len(blocks) != 4 {
+		t.Fatalf("got %d blocks, expected 4", len(blocks))
+	}
+	if got := blocks[0].Params.String(); got != "a=t,f=24,i=42,o=z,q=1,s=16,t=d,v=8" {
+		t.Errorf("transmit: got %q", got)
+	}
+	for _, bl := range blocks[1:] {
+		if got := bl.Params.String(); got != "a=p,i=42,q=1" {
+			t.Errorf("place: got %q", got)
+		}
+		if len(bl.Payload) != 0 {
+			t.Error("place: unexpected payload")
+		}
+	}
+}
+
+func TestTransmitFile(t *testing.T) {
+	f, err := os.Open("testdata/go-favicon-1.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	img, err := enc.TransmitFile(&buf, 7, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("%+v", img)
+	var bl *Block
+	for bl = range extractBlocks(buf.Bytes()) {
+		break
+	}
+	if bl.Params['a'] != "t" || bl.Params['i'] != "7" || bl.Params['f'] != "100" {
+		t.Errorf("unexpected params: %s", bl.Params)
+	}
+	if bl.Params['s'] != strconv.Itoa(img.Width) || bl.Params['v'] != strconv.Itoa(img.Height) {
+		t.Errorf("size mismatch: %s vs %+v", bl.Params, img)
+	}
+}
+
+func TestTransmitZeroID(t *testing.T) {
+	var enc kittyimg.Encoder
+	var buf bytes.Buffer
+	if _, err := enc.Transmit(&buf, 0, newTestImage(1, 1)); !errors.Is(err, kittyimg.ErrInvalidID) {
+		t.Errorf("got %v, expected ErrInvalidID", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("unexpected output: %q", buf.String())
+	}
+}
@@ -0,0 +1,88 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import (
+	"context"
+	"image"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// WindowSize is the size of a terminal window.
+type WindowSize struct {
+	Columns, Rows int // in cells
+	Width, Height int // in pixels, 0 if unknown
+}
+
+// CellSize returns the size of a cell in pixels, or a zero size if unknown.
+func (ws *WindowSize) CellSize() image.Point {
+	if ws.Columns <= 0 || ws.Rows <= 0 {
+		return image.Point{}
+	}
+	return image.Pt(ws.Width/ws.Columns, ws.Height/ws.Rows)
+}
+
+// windowSizeRequest requests the size of the text area (CSI 14 t) and of a
+// cell (CSI 16 t) in pixels.
+const windowSizeRequest = "\033[14t\033[16t"
+
+// windowSizeReplyRE matches the replies to windowSizeRequest:
+// CSI 4 ; height ; width t and CSI 6 ; height ; width t.
+var windowSizeReplyRE = regexp.MustCompile("\033\\[([46]);([0-9]+);([0-9]+)t")
+
+// GetWindowSize returns the size of the terminal tty in cells and in pixels.
+//
+// The size is read with the TIOCGWINSZ ioctl. If the size in pixels is not
+// reported that way (it is not available on all platforms nor with all
+// terminals), the terminal is queried with CSI 14 t and CSI 16 t, in raw
+// mode like [Detect]. If the terminal doesn't reply, Width and Height are 0.
+//
+// If ctx has no deadline, [DetectTimeout] is applied to the query.
+func GetWindowSize(ctx context.Context, tty *os.File) (*WindowSize, error) {
+	fd, err := terminalFd(tty)
+	if err != nil {
+		return nil, err
+	}
+	ws, err := getWinsize(fd)
+	if err != nil {
+		return nil, err
+	}
+	if ws.Width > 0 && ws.Height > 0 {
+		return ws, nil
+	}
+
+	b, _, err := exchange(ctx, tty, []byte(windowSizeRequest))
+	if err != nil {
+		return nil, err
+	}
+	var cell image.Point
+	for _, m := range windowSizeReplyRE.FindAllSubmatch(b, -1) {
+		height, _ := strconv.Atoi(string(m[2]))
+		width, _ := strconv.Atoi(string(m[3]))
+		if m[1][0] == '4' {
+			ws.Width, ws.Height = width, height
+		} else {
+			cell = image.Pt(width, height)
+		}
+	}
+	if ws.Width <= 0 || ws.Height <= 0 {
+		ws.Width, ws.Height = ws.Columns*cell.X, ws.Rows*cell.Y
+	}
+	return ws, nil
+}
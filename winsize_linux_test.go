@@ -0,0 +1,81 @@
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/dolmen-go/kittyimg"
+)
+
+func setWinsize(t *testing.T, f *os.File, ws *unix.Winsize) {
+	t.Helper()
+	if err := unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetWindowSize(t *testing.T) {
+	master, tty := openPTY(t)
+	setWinsize(t, master, &unix.Winsize{Row: 24, Col: 80, Xpixel: 800, Ypixel: 480})
+
+	ws, err := kittyimg.GetWindowSize(context.Background(), tty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *ws != (kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480}) {
+		t.Errorf("got %+v", *ws)
+	}
+	if cell := ws.CellSize(); cell != image.Pt(10, 20) {
+		t.Errorf("got cell size %v", cell)
+	}
+}
+
+func TestGetWindowSizeQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		reply    string
+		expected kittyimg.WindowSize
+	}{
+		{"text-area", "\033[4;480;800t\033[6;20;10t\033[?62c", kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480}},
+		{"cell", "\033[6;20;10t\033[?62c", kittyimg.WindowSize{Columns: 80, Rows: 24, Width: 800, Height: 480}},
+		{"unsupported", "\033[?1;2c", kittyimg.WindowSize{Columns: 80, Rows: 24}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			master, tty := openPTY(t)
+			setWinsize(t, master, &unix.Winsize{Row: 24, Col: 80})
+			received := fakeTerminal(t, master, tc.reply)
+
+			ws, err := kittyimg.GetWindowSize(context.Background(), tty)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *ws != tc.expected {
+				t.Errorf("got %+v, expected %+v", *ws, tc.expected)
+			}
+			if q := <-received; !bytes.HasPrefix(q, []byte("\033[14t\033[16t")) {
+				t.Errorf("got request %q", q)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+/*
+   Copyright 2021-2026 Olivier Mengué.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package kittyimg
+
+import "golang.org/x/sys/unix"
+
+// getWinsize returns the size of the terminal fd, as reported by the
+// TIOCGWINSZ ioctl.
+func getWinsize(fd int) (*WindowSize, error) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return nil, err
+	}
+	return &WindowSize{
+		Columns: int(ws.Col),
+		Rows:    int(ws.Row),
+		Width:   int(ws.Xpixel),
+		Height:  int(ws.Ypixel),
+	}, nil
+}